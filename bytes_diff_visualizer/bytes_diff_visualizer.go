@@ -3,15 +3,25 @@ package bytes_diff_visualizer
 import (
 	"bytes"
 	"fmt"
+	"io"
+	"os"
 	"strings"
 
+	"github.com/codecrafters-io/tester-utils/color_policy"
 	"github.com/fatih/color"
 )
 
 // VisualizeByteDiff visualizes the difference between two byte slices, returning lines to be presented to the user.
 //
-// The lines will include ANSI escape codes to colorize the output.
+// The lines will include ANSI escape codes to colorize the output, unless disabled by the color policy (see
+// color_policy) for stdout. Use VisualizeByteDiffForWriter if the lines are written elsewhere.
 func VisualizeByteDiff(actual []byte, expected []byte) []string {
+	return VisualizeByteDiffForWriter(actual, expected, os.Stdout)
+}
+
+// VisualizeByteDiffForWriter is like VisualizeByteDiff, but only colorizes the lines if the color policy allows colors
+// for w (like the writer passed to logger.GetLoggerWithWriter or logger.SetDefaultWriter).
+func VisualizeByteDiffForWriter(actual []byte, expected []byte, w io.Writer) []string {
 	// If both are exactly the same, return an empty slice
 	if bytes.Equal(actual, expected) {
 		return []string{}
@@ -41,6 +51,9 @@ func VisualizeByteDiff(actual []byte, expected []byte) []string {
 	byteRangeStart := intmax(0, firstDiffIndex-(totalByteCountToDisplay/2))
 	byteRangeEnd := intmin(byteRangeStart+totalByteCountToDisplay, intmax(len(actual), len(expected)))
 
+	expectedColor := color_policy.NewColor(w, color.FgHiGreen)
+	actualColor := color_policy.NewColor(w, color.FgHiRed)
+
 	linesBuffer := bytes.NewBuffer([]byte{})
 
 	leftHeader := PadRight(fmt.Sprintf("Expected (bytes %v-%v), hexadecimal:", byteRangeStart, byteRangeEnd), " ", 60)
@@ -49,8 +62,8 @@ func VisualizeByteDiff(actual []byte, expected []byte) []string {
 	for i := byteRangeStart; i < intmin(byteRangeEnd, len(expected)); i += byteCountPerLine {
 		end := intmin(i+byteCountPerLine, len(expected))
 
-		bytesAsHex := formatHexWithColorizedByte(expected, i, firstDiffIndex, end, color.FgHiGreen)
-		bytesAsAscii := formatAsciiWithColorizedByte(expected, i, firstDiffIndex, end, color.FgHiGreen)
+		bytesAsHex := formatHexWithColorizedByte(expected, i, firstDiffIndex, end, expectedColor)
+		bytesAsAscii := formatAsciiWithColorizedByte(expected, i, firstDiffIndex, end, expectedColor)
 
 		fmt.Fprintf(linesBuffer, "%v| %v\n", bytesAsHex, bytesAsAscii)
 	}
@@ -63,8 +76,8 @@ func VisualizeByteDiff(actual []byte, expected []byte) []string {
 	for i := byteRangeStart; i < intmin(byteRangeEnd, len(actual)); i += byteCountPerLine {
 		end := intmin(i+byteCountPerLine, len(actual))
 
-		bytesAsHex := formatHexWithColorizedByte(actual, i, firstDiffIndex, end, color.FgHiRed)
-		bytesAsAscii := formatAsciiWithColorizedByte(actual, i, firstDiffIndex, end, color.FgHiRed)
+		bytesAsHex := formatHexWithColorizedByte(actual, i, firstDiffIndex, end, actualColor)
+		bytesAsAscii := formatAsciiWithColorizedByte(actual, i, firstDiffIndex, end, actualColor)
 
 		fmt.Fprintf(linesBuffer, "%v| %v\n", bytesAsHex, bytesAsAscii)
 	}
@@ -91,19 +104,21 @@ func formatBytesAsAscii(value []byte) string {
 	return strings.Join(asciiRepresentations, "")
 }
 
-func formatHexWithColorizedByte(value []byte, i int, firstDiffIndex int, end int, chosenColor color.Attribute) string {
+func formatHexWithColorizedByte(value []byte, i int, firstDiffIndex int, end int, chosenColor *color.Color) string {
 	if firstDiffIndex >= i && firstDiffIndex < end {
-		return PadRight(formatHexWithColorizedByteHelper(value, i, firstDiffIndex, end, chosenColor), " ", 69)
+		// ANSI escape codes take up space in the string but not on screen
+		escapeCodesLength := len(colorizeString(chosenColor, "00")) - len("00")
+		return PadRight(formatHexWithColorizedByteHelper(value, i, firstDiffIndex, end, chosenColor), " ", 60+escapeCodesLength)
 	} else {
 		return PadRight(formatBytesAsHex(value[i:end]), " ", 60)
 	}
 }
 
-func formatHexWithColorizedByteHelper(value []byte, i int, firstDiffIndex int, end int, chosenColor color.Attribute) string {
+func formatHexWithColorizedByteHelper(value []byte, i int, firstDiffIndex int, end int, chosenColor *color.Color) string {
 	return formatBytesAsHex(value[i:firstDiffIndex]) + " " + colorizeString(chosenColor, formatBytesAsHex(value[firstDiffIndex:firstDiffIndex+1])) + " " + formatBytesAsHex(value[firstDiffIndex+1:end])
 }
 
-func formatAsciiWithColorizedByte(value []byte, i int, firstDiffIndex int, end int, chosenColor color.Attribute) string {
+func formatAsciiWithColorizedByte(value []byte, i int, firstDiffIndex int, end int, chosenColor *color.Color) string {
 	if firstDiffIndex >= i && firstDiffIndex < end {
 		return formatAsciiWithColorizedByteHelper(value, i, firstDiffIndex, end, chosenColor)
 	} else {
@@ -111,7 +126,7 @@ func formatAsciiWithColorizedByte(value []byte, i int, firstDiffIndex int, end i
 	}
 }
 
-func formatAsciiWithColorizedByteHelper(value []byte, i int, firstDiffIndex int, end int, chosenColor color.Attribute) string {
+func formatAsciiWithColorizedByteHelper(value []byte, i int, firstDiffIndex int, end int, chosenColor *color.Color) string {
 	return formatBytesAsAscii(value[i:firstDiffIndex]) + colorizeString(chosenColor, formatBytesAsAscii(value[firstDiffIndex:firstDiffIndex+1])) + formatBytesAsAscii(value[firstDiffIndex+1:end])
 }
 
@@ -139,9 +154,8 @@ func intmin(a int, b int) int {
 	return b
 }
 
-func colorizeString(colorToUse color.Attribute, msg string) string {
-	c := color.New(colorToUse)
-	return c.Sprint(msg)
+func colorizeString(colorToUse *color.Color, msg string) string {
+	return colorToUse.Sprint(msg)
 }
 
 func PadRight(str, pad string, length int) string {
@@ -1,11 +1,13 @@
 package bytes_diff_visualizer
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"regexp"
 	"testing"
 
+	"github.com/codecrafters-io/tester-utils/color_policy"
 	"github.com/fatih/color"
 	"github.com/stretchr/testify/assert"
 )
@@ -115,6 +117,12 @@ func TestVisualizeByteDiffWorksWithLongerSequences(t *testing.T) {
 }
 
 func TestVisualizeByteDiffWorksWithColoredOutput(t *testing.T) {
+	color_policy.Set(color_policy.AlwaysColorPolicy)
+	defer color_policy.Set(color_policy.DefaultColorPolicy)
+
+	green := color_policy.NewColor(os.Stdout, color.FgHiGreen)
+	red := color_policy.NewColor(os.Stdout, color.FgHiRed)
+
 	actual := []byte("Hello, World!")
 	expected := []byte("Hello, Go!")
 
@@ -125,10 +133,10 @@ func TestVisualizeByteDiffWorksWithColoredOutput(t *testing.T) {
 
 	expectedLines := []string{
 		"Expected (bytes 0-13), hexadecimal:                         | ASCII:",
-		"48 65 6c 6c 6f 2c 20 " + colorizeString(color.FgHiGreen, "47") + " 6f 21                               | Hello, " + colorizeString(color.FgHiGreen, "G") + "o!",
+		"48 65 6c 6c 6f 2c 20 " + colorizeString(green, "47") + " 6f 21                               | Hello, " + colorizeString(green, "G") + "o!",
 		"",
 		"Actual (bytes 0-13), hexadecimal:                           | ASCII:",
-		"48 65 6c 6c 6f 2c 20 " + colorizeString(color.FgHiRed, "57") + " 6f 72 6c 64 21                      | Hello, " + colorizeString(color.FgHiRed, "W") + "orld!",
+		"48 65 6c 6c 6f 2c 20 " + colorizeString(red, "57") + " 6f 72 6c 64 21                      | Hello, " + colorizeString(red, "W") + "orld!",
 	}
 
 	for i, expectedLine := range expectedLines {
@@ -153,3 +161,31 @@ func stripANSI(data string) string {
 
 	return string(re.ReplaceAll([]byte(data), []byte("")))
 }
+
+func TestVisualizeByteDiffWithoutColors(t *testing.T) {
+	color_policy.Set(color_policy.NeverColorPolicy)
+	defer color_policy.Set(color_policy.DefaultColorPolicy)
+
+	lines := VisualizeByteDiff([]byte("Hello, World!"), []byte("Hello, Go!"))
+
+	for _, line := range lines {
+		assert.NotContains(t, line, "\x1b[")
+	}
+
+	assert.Equal(t, "48 65 6c 6c 6f 2c 20 47 6f 21                               | Hello, Go!", lines[1])
+}
+
+func TestVisualizeByteDiffForWriter(t *testing.T) {
+	color_policy.Set(color_policy.AutoColorPolicy)
+	defer color_policy.Set(color_policy.DefaultColorPolicy)
+
+	// Buffers aren't terminals, so no colors are used (regardless of whether stdout is a terminal)
+	buffer := bytes.NewBuffer([]byte{})
+
+	for _, line := range VisualizeByteDiffForWriter([]byte("Hello, World!"), []byte("Hello, Go!"), buffer) {
+		assert.NotContains(t, line, "\x1b[")
+	}
+
+	color_policy.Set(color_policy.AlwaysColorPolicy)
+	assert.Contains(t, VisualizeByteDiffForWriter([]byte("Hello, World!"), []byte("Hello, Go!"), buffer)[1], "\x1b[")
+}
@@ -0,0 +1,97 @@
+package color_policy
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
+)
+
+// ColorPolicy controls whether output includes ANSI color codes
+type ColorPolicy string
+
+const (
+	// DefaultColorPolicy emits colors unless NO_COLOR is set. Terminals aren't detected, since fixtures are recorded
+	// with colors. Used when CODECRAFTERS_COLOR isn't set.
+	DefaultColorPolicy ColorPolicy = "default"
+
+	// AlwaysColorPolicy always emits colors, even if NO_COLOR is set
+	AlwaysColorPolicy ColorPolicy = "always"
+
+	// NeverColorPolicy never emits colors
+	NeverColorPolicy ColorPolicy = "never"
+
+	// AutoColorPolicy emits colors only when writing to a terminal, and NO_COLOR isn't set and TERM isn't "dumb"
+	AutoColorPolicy ColorPolicy = "auto"
+)
+
+var (
+	currentPolicy      = DefaultColorPolicy
+	currentPolicyMutex sync.Mutex
+)
+
+// Parse converts a string like "auto" into a ColorPolicy
+func Parse(value string) (ColorPolicy, error) {
+	switch ColorPolicy(value) {
+	case AlwaysColorPolicy, NeverColorPolicy, AutoColorPolicy:
+		return ColorPolicy(value), nil
+	default:
+		return "", fmt.Errorf("color policy must be one of always, never, auto (got %q)", value)
+	}
+}
+
+// Set changes the process-wide color policy
+func Set(policy ColorPolicy) {
+	currentPolicyMutex.Lock()
+	defer currentPolicyMutex.Unlock()
+
+	currentPolicy = policy
+}
+
+// Get returns the process-wide color policy
+func Get() ColorPolicy {
+	currentPolicyMutex.Lock()
+	defer currentPolicyMutex.Unlock()
+
+	return currentPolicy
+}
+
+// ShouldColorize returns true if output written to w should include colors under the current policy
+func ShouldColorize(w io.Writer) bool {
+	switch Get() {
+	case NeverColorPolicy:
+		return false
+	case DefaultColorPolicy:
+		_, isSet := os.LookupEnv("NO_COLOR")
+		return !isSet
+	case AutoColorPolicy:
+		if _, isSet := os.LookupEnv("NO_COLOR"); isSet || os.Getenv("TERM") == "dumb" {
+			return false
+		}
+
+		file, ok := w.(*os.File)
+		if !ok {
+			return false
+		}
+
+		return isatty.IsTerminal(file.Fd()) || isatty.IsCygwinTerminal(file.Fd())
+	default:
+		return true
+	}
+}
+
+// NewColor returns a color.Color that is enabled or disabled based on ShouldColorize(w), regardless of color.NoColor
+func NewColor(w io.Writer, attributes ...color.Attribute) *color.Color {
+	c := color.New(attributes...)
+
+	if ShouldColorize(w) {
+		c.EnableColor()
+	} else {
+		c.DisableColor()
+	}
+
+	return c
+}
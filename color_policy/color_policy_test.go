@@ -0,0 +1,68 @@
+package color_policy
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	policy, err := Parse("auto")
+	assert.NoError(t, err)
+	assert.Equal(t, AutoColorPolicy, policy)
+
+	_, err = Parse("sometimes")
+	assert.Error(t, err)
+}
+
+func TestShouldColorize(t *testing.T) {
+	defer Set(DefaultColorPolicy)
+
+	Set(AlwaysColorPolicy)
+	assert.True(t, ShouldColorize(&bytes.Buffer{}))
+
+	Set(NeverColorPolicy)
+	assert.False(t, ShouldColorize(&bytes.Buffer{}))
+
+	// Not a terminal
+	Set(AutoColorPolicy)
+	assert.False(t, ShouldColorize(&bytes.Buffer{}))
+}
+
+func TestShouldColorizeRespectsNoColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+
+	Set(AutoColorPolicy)
+	defer Set(DefaultColorPolicy)
+
+	assert.False(t, ShouldColorize(&bytes.Buffer{}))
+}
+
+func TestDefaultPolicyRespectsNoColor(t *testing.T) {
+	Set(DefaultColorPolicy)
+	defer Set(DefaultColorPolicy)
+
+	t.Setenv("NO_COLOR", "") // Restores the original value once the test is done
+	os.Unsetenv("NO_COLOR")
+	assert.True(t, ShouldColorize(&bytes.Buffer{}))
+
+	t.Setenv("NO_COLOR", "1")
+	assert.False(t, ShouldColorize(&bytes.Buffer{}))
+
+	// Explicitly asking for colors overrides NO_COLOR
+	Set(AlwaysColorPolicy)
+	assert.True(t, ShouldColorize(&bytes.Buffer{}))
+}
+
+func TestNewColor(t *testing.T) {
+	defer Set(DefaultColorPolicy)
+
+	Set(AlwaysColorPolicy)
+	assert.Equal(t, "\x1b[31mred\x1b[0m", NewColor(&bytes.Buffer{}, color.FgRed).Sprint("red"))
+
+	Set(NeverColorPolicy)
+	assert.Equal(t, "red", NewColor(&bytes.Buffer{}, color.FgRed).Sprint("red"))
+}
@@ -12,6 +12,7 @@ import (
 
 	"io"
 	"os/exec"
+	"sync/atomic"
 	"syscall"
 
 	"github.com/codecrafters-io/tester-utils/linewriter"
@@ -33,16 +34,56 @@ type Executable struct {
 	// ShouldUsePtyOutputStreams controls whether the executable's standard streams should be set to PTY instead of pipes.
 	ShouldUsePtyOutputStreams bool
 
+	// StdioHandlerFactory can be set to use a custom StdioHandler (like NewPtyInPipesOutStdioHandler). It is called once
+	// per run. Takes precedence over ShouldUsePtyOutputStreams.
+	StdioHandlerFactory func() StdioHandler
+
 	// WorkingDir can be set before calling Start or Run to customize the working directory of the executable.
 	WorkingDir string
 
+	// ExtraFiles are passed to the executable as additional open files, mapped to fd 3 and up (see exec.Cmd.ExtraFiles).
+	//
+	// The caller owns these files and is responsible for closing them.
+	ExtraFiles []*os.File
+
+	// StdinFile can be set to wire the executable's stdin to a file (or one end of a socket pair, see NewSocketPair)
+	// instead of a pipe. RunWithStdin can't be used when this is set.
+	//
+	// The caller owns this file and is responsible for closing it.
+	StdinFile *os.File
+
+	// StdoutFile can be set to wire the executable's stdout to a file (or one end of a socket pair, see NewSocketPair)
+	// instead of a pipe. Stdout isn't captured or logged when this is set.
+	//
+	// The caller owns this file and is responsible for closing it.
+	StdoutFile *os.File
+
 	// Process is the os.Process object for the executable.
 	// TODO: See if this actually needs to be exported?
 	Process *os.Process
 
+	// Context, if set, bounds the executable's lifetime: Start fails once it is done, and a running process (along with
+	// its process group) is killed when it is done. Output that is flushed afterwards (like a partial line) isn't passed
+	// to the logger funcs, so it can't show up in later logs.
+	Context context.Context
+
+	// LineWriterOptions configure how output is split into lines before being passed to the logger func (like
+	// linewriter.WithPartialLineMarker). They don't affect the captured output in ExecutableResult.
+	LineWriterOptions []linewriter.Option
+
+	// TruncatedOutputLoggerFunc, if set, is called w/ output that wasn't passed to the logger because it exceeded the
+	// 30KB limit. Useful for writing complete output to a log file (see logger.Logger.FullLogOnlyln).
+	TruncatedOutputLoggerFunc func(string)
+
 	// loggerFunc is the function called w/ output from the executable.
 	loggerFunc func(string)
 
+	// args are the arguments the executable was last started with, used by Restart
+	args []string
+
+	// runningPid is the pid of the running process (0 if none), readable from any goroutine (see Terminate)
+	runningPid atomic.Int64
+
 	// These are set & removed together
 	atleastOneReadDone bool
 	memoryMonitor      *memoryMonitor // Monitors process memory usage and kills if limit exceeded
@@ -53,7 +94,7 @@ type Executable struct {
 	stderrBuffer       *bytes.Buffer
 	stderrBytes        []byte
 	stderrLineWriter   *linewriter.LineWriter
-	stdioHandler       stdioHandler
+	stdioHandler       StdioHandler
 	stdoutBuffer       *bytes.Buffer
 	stdoutBytes        []byte
 	stdoutLineWriter   *linewriter.LineWriter
@@ -91,7 +132,14 @@ func (e *Executable) Clone() *Executable {
 		loggerFunc:                e.loggerFunc,
 		WorkingDir:                e.WorkingDir,
 		ShouldUsePtyOutputStreams: e.ShouldUsePtyOutputStreams,
+		StdioHandlerFactory:       e.StdioHandlerFactory,
 		MemoryLimitInBytes:        e.MemoryLimitInBytes,
+		ExtraFiles:                e.ExtraFiles,
+		StdinFile:                 e.StdinFile,
+		StdoutFile:                e.StdoutFile,
+		LineWriterOptions:         e.LineWriterOptions,
+		TruncatedOutputLoggerFunc: e.TruncatedOutputLoggerFunc,
+		Context:                   e.Context,
 	}
 }
 
@@ -115,6 +163,22 @@ func NewVerboseExecutable(path string, loggerFunc func(string)) *Executable {
 	}
 }
 
+// withContext wraps a logger func so that it drops output once Context (if set) is done
+func (e *Executable) withContext(loggerFunc func(string)) func(string) {
+	ctx := e.Context
+	if ctx == nil {
+		return loggerFunc
+	}
+
+	return func(line string) {
+		if ctx.Err() != nil {
+			return
+		}
+
+		loggerFunc(line)
+	}
+}
+
 func (e *Executable) isRunning() bool {
 	return e.cmd != nil
 }
@@ -124,9 +188,13 @@ func (e *Executable) HasExited() bool {
 }
 
 func (e *Executable) initializeStdioHandler() {
-	e.stdioHandler = &pipeTrioStdioHandler{}
-	if e.ShouldUsePtyOutputStreams {
-		e.stdioHandler = &pipeInPtysOutStdioHandler{}
+	switch {
+	case e.StdioHandlerFactory != nil:
+		e.stdioHandler = e.StdioHandlerFactory()
+	case e.ShouldUsePtyOutputStreams:
+		e.stdioHandler = NewPipeInPtysOutStdioHandler()
+	default:
+		e.stdioHandler = NewPipeTrioStdioHandler()
 	}
 }
 
@@ -138,6 +206,15 @@ func (e *Executable) Start(args ...string) error {
 		return errors.New("process already in progress")
 	}
 
+	parentCtx := e.Context
+	if parentCtx == nil {
+		parentCtx = context.Background()
+	}
+
+	if err := parentCtx.Err(); err != nil {
+		return fmt.Errorf("not starting %s: %w", filepath.Base(e.Path), err)
+	}
+
 	// Get the absolute path for e.Path
 	absolutePath, err := resolveAbsolutePath(e.Path)
 
@@ -156,7 +233,7 @@ func (e *Executable) Start(args ...string) error {
 		return fmt.Errorf("%s (resolved to %s) is not an executable file", e.Path, absolutePath)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(e.TimeoutInMilliseconds)*time.Millisecond)
+	ctx, cancel := context.WithTimeout(parentCtx, time.Duration(e.TimeoutInMilliseconds)*time.Millisecond)
 	e.ctxWithTimeout = ctx
 	e.ctxCancelFunc = cancel
 
@@ -173,6 +250,20 @@ func (e *Executable) Start(args ...string) error {
 	cmd.Env = getSafeEnvironmentVariables()
 	cmd.Dir = e.WorkingDir
 	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL) // Kill the whole process group
+		return cmd.Process.Kill()
+	}
+	cmd.ExtraFiles = e.ExtraFiles
+
+	// Streams wired to files by the caller are left untouched by the stdio handler
+	if e.StdinFile != nil {
+		cmd.Stdin = e.StdinFile
+	}
+
+	if e.StdoutFile != nil {
+		cmd.Stdout = e.StdoutFile
+	}
 
 	e.memoryMonitor = newMemoryMonitor(e.MemoryLimitInBytes)
 
@@ -181,11 +272,11 @@ func (e *Executable) Start(args ...string) error {
 
 	e.stdoutBytes = []byte{}
 	e.stdoutBuffer = bytes.NewBuffer(e.stdoutBytes)
-	e.stdoutLineWriter = linewriter.New(newLoggerWriter(e.loggerFunc), 500*time.Millisecond)
+	e.stdoutLineWriter = linewriter.New(newLoggerWriter(e.withContext(e.loggerFunc)), 500*time.Millisecond, e.LineWriterOptions...)
 
 	e.stderrBytes = []byte{}
 	e.stderrBuffer = bytes.NewBuffer(e.stderrBytes)
-	e.stderrLineWriter = linewriter.New(newLoggerWriter(e.loggerFunc), 500*time.Millisecond)
+	e.stderrLineWriter = linewriter.New(newLoggerWriter(e.withContext(e.loggerFunc)), 500*time.Millisecond, e.LineWriterOptions...)
 
 	// Initialize stdio handler
 	e.initializeStdioHandler()
@@ -218,6 +309,8 @@ func (e *Executable) Start(args ...string) error {
 
 	// At this point, it is safe to set e.cmd as cmd, if any of the above steps fail, we don't want to leave e.cmd in an inconsistent state
 	e.cmd = cmd
+	e.args = append([]string{}, args...)
+	e.runningPid.Store(int64(cmd.Process.Pid))
 
 	// Start memory monitoring for RSS-based memory limiting (Linux only, no-op on other platforms)
 	e.memoryMonitor.start(cmd.Process.Pid)
@@ -229,6 +322,12 @@ func (e *Executable) Start(args ...string) error {
 }
 
 func (e *Executable) setupIORelay(source io.Reader, destination1 io.Writer, destination2 io.Writer) {
+	// The stream was wired to a file by the caller, there's nothing to relay
+	if source == nil {
+		go func() { e.readDone <- true }()
+		return
+	}
+
 	go func() {
 		combinedDestination := io.MultiWriter(destination1, destination2)
 		// Limit to 30KB (~250 lines at 120 chars per line)
@@ -243,7 +342,14 @@ func (e *Executable) setupIORelay(source io.Reader, destination1 io.Writer, dest
 		}
 
 		if bytesWritten == 30000 {
-			e.loggerFunc("Warning: Logs exceeded allowed limit, output might be truncated.\n")
+			e.withContext(e.loggerFunc)("Warning: Logs exceeded allowed limit, output might be truncated.\n")
+		}
+
+		// Wait() closes the streams once reads are done, so truncated output must be relayed before that
+		if bytesWritten == 30000 && e.TruncatedOutputLoggerFunc != nil {
+			truncatedOutputLineWriter := linewriter.New(newLoggerWriter(e.withContext(e.TruncatedOutputLoggerFunc)), 500*time.Millisecond)
+			io.Copy(truncatedOutputLineWriter, source) // Errors are ignored, same as above
+			truncatedOutputLineWriter.Flush()
 		}
 
 		e.atleastOneReadDone = true
@@ -269,6 +375,10 @@ func (e *Executable) Run(args ...string) (ExecutableResult, error) {
 func (e *Executable) RunWithStdin(stdin []byte, args ...string) (ExecutableResult, error) {
 	var err error
 
+	if e.StdinFile != nil {
+		return ExecutableResult{}, errors.New("RunWithStdin can't be used when StdinFile is set")
+	}
+
 	if err = e.Start(args...); err != nil {
 		return ExecutableResult{}, err
 	}
@@ -300,6 +410,9 @@ func formatBytesHumanReadable(bytes int64) string {
 // ErrMemoryLimitExceeded is returned when a process exceeds its memory limit
 var ErrMemoryLimitExceeded = errors.New("process exceeded memory limit")
 
+// ErrKillEscalated is returned by Kill when the program ignored SIGTERM and had to be killed with SIGKILL
+var ErrKillEscalated = errors.New("program failed to exit in 2 seconds after receiving sigterm")
+
 // Wait waits for the program to finish and returns the result.
 func (e *Executable) Wait() (ExecutableResult, error) {
 	defer func() {
@@ -310,6 +423,7 @@ func (e *Executable) Wait() (ExecutableResult, error) {
 
 		e.atleastOneReadDone = false
 		e.cmd = nil
+		e.runningPid.Store(0)
 		e.ctxCancelFunc = nil
 		e.ctxWithTimeout = nil
 		e.memoryMonitor = nil
@@ -374,35 +488,80 @@ func (e *Executable) Wait() (ExecutableResult, error) {
 
 // Kill terminates the program
 func (e *Executable) Kill() error {
+	_, err := e.kill()
+	return err
+}
+
+// Terminate sends SIGKILL to the program's process group without waiting for it to exit.
+//
+// Unlike Kill, this is safe to call while another goroutine is in Run or Wait (which return once the process exits).
+func (e *Executable) Terminate() {
+	pid := int(e.runningPid.Load())
+	if pid == 0 {
+		return
+	}
+
+	syscall.Kill(pid, syscall.SIGKILL)
+	syscall.Kill(-pid, syscall.SIGKILL) // Kill the whole process group
+}
+
+// Restart terminates the program (if it is running) and starts it again with the same arguments.
+//
+// The returned ExecutableResult describes how the previous process exited. Programs that ignore SIGTERM are killed
+// with SIGKILL (exit code 137) and restarted as usual.
+func (e *Executable) Restart() (ExecutableResult, error) {
+	if e.args == nil {
+		return ExecutableResult{}, errors.New("process was never started")
+	}
+
+	result, err := e.kill()
+	if err != nil && !errors.Is(err, ErrKillEscalated) {
+		return result, err
+	}
+
+	if err := e.Start(e.args...); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// kill sends SIGTERM to the program, escalating to SIGKILL if it doesn't exit in 2 seconds
+func (e *Executable) kill() (ExecutableResult, error) {
 	if !e.isRunning() {
-		return nil
+		return ExecutableResult{}, nil
+	}
+
+	type waitResult struct {
+		result ExecutableResult
+		err    error
 	}
 
-	doneChannel := make(chan error, 1)
+	doneChannel := make(chan waitResult, 1)
 
 	go func() {
 		syscall.Kill(e.cmd.Process.Pid, syscall.SIGTERM)  // Don't know if this is required
 		syscall.Kill(-e.cmd.Process.Pid, syscall.SIGTERM) // Kill the whole process group
-		_, err := e.Wait()
-		doneChannel <- err
+		result, err := e.Wait()
+		doneChannel <- waitResult{result: result, err: err}
 	}()
 
-	var err error
 	select {
-	case doneError := <-doneChannel:
-		err = doneError
+	case done := <-doneChannel:
+		return done.result, done.err
 	case <-time.After(2 * time.Second):
 		cmd := e.cmd
-		if cmd != nil {
-			err = fmt.Errorf("program failed to exit in 2 seconds after receiving sigterm")
-			syscall.Kill(cmd.Process.Pid, syscall.SIGKILL)  // Don't know if this is required
-			syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL) // Kill the whole process group
-
-			<-doneChannel // Wait for Wait() to return
+		if cmd == nil {
+			done := <-doneChannel
+			return done.result, done.err
 		}
-	}
 
-	return err
+		syscall.Kill(cmd.Process.Pid, syscall.SIGKILL)  // Don't know if this is required
+		syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL) // Kill the whole process group
+
+		done := <-doneChannel // Wait for Wait() to return
+		return done.result, ErrKillEscalated
+	}
 }
 
 // getSafeEnvironmentVariables filters out environment variables starting with CODECRAFTERS_SECRET
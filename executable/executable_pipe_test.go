@@ -2,11 +2,16 @@ package executable
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"os"
+	"path"
 	"runtime"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/codecrafters-io/tester-utils/linewriter"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -276,3 +281,152 @@ func TestPathResolutionWithDifferentWorkingDir(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "test-message\n", string(result.Stdout))
 }
+
+func TestRestart(t *testing.T) {
+	e := NewExecutable("./test_helpers/sleep_for.sh")
+
+	_, err := e.Restart()
+	assertErrorContains(t, err, "never started")
+
+	tempDir, err := os.MkdirTemp("", "executable_test_")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	e.WorkingDir = tempDir
+
+	err = e.Start("60")
+	assert.NoError(t, err)
+
+	result, err := e.Restart()
+	assert.NoError(t, err)
+	assert.Equal(t, 143, result.ExitCode) // SIGTERM
+	assert.True(t, e.isRunning(), "Expected to be running after restart")
+	assert.Equal(t, []string{"60"}, e.cmd.Args[1:])
+	assert.Equal(t, tempDir, e.cmd.Dir)
+
+	err = e.Kill()
+	assert.NoError(t, err)
+}
+
+func TestRestartIgnoringSIGTERM(t *testing.T) {
+	e := NewExecutable("bash")
+
+	readyFile := path.Join(t.TempDir(), "ready")
+	err := e.Start("-c", fmt.Sprintf("trap '' TERM; touch %s; while true; do sleep 0.1; done", readyFile))
+	assert.NoError(t, err)
+
+	// Wait for the trap to be set
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(readyFile)
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	result, err := e.Restart()
+	assert.NoError(t, err)
+	assert.Equal(t, 137, result.ExitCode) // SIGKILL
+	assert.True(t, e.isRunning(), "Expected to be running after restart")
+
+	e.Terminate()
+	e.Wait()
+}
+
+func TestExtraFiles(t *testing.T) {
+	reader, writer, err := os.Pipe()
+	assert.NoError(t, err)
+	defer reader.Close()
+
+	e := NewExecutable("bash")
+	e.ExtraFiles = []*os.File{writer}
+
+	result, err := e.Run("-c", "echo hey >&3")
+	writer.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.ExitCode)
+
+	output, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, "hey\n", string(output))
+}
+
+func TestStdinFile(t *testing.T) {
+	stdinFile, err := os.CreateTemp("", "executable_test_")
+	assert.NoError(t, err)
+	defer os.Remove(stdinFile.Name())
+	defer stdinFile.Close()
+
+	_, err = stdinFile.WriteString("from a file\n")
+	assert.NoError(t, err)
+	_, err = stdinFile.Seek(0, io.SeekStart)
+	assert.NoError(t, err)
+
+	e := NewExecutable("cat")
+	e.StdinFile = stdinFile
+
+	result, err := e.Run()
+	assert.NoError(t, err)
+	assert.Equal(t, "from a file\n", string(result.Stdout))
+
+	_, err = e.RunWithStdin([]byte("ignored"))
+	assertErrorContains(t, err, "StdinFile is set")
+}
+
+func TestStdoutSocketPair(t *testing.T) {
+	parentEnd, childEnd, err := NewSocketPair()
+	assert.NoError(t, err)
+	defer parentEnd.Close()
+
+	e := NewExecutable("./test_helpers/stdout_echo.sh")
+	e.StdoutFile = childEnd
+
+	result, err := e.Run("hey")
+	childEnd.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, "", string(result.Stdout))
+
+	output, err := io.ReadAll(parentEnd)
+	assert.NoError(t, err)
+	assert.Equal(t, "hey\n", string(output))
+}
+
+func TestTruncatedOutputLoggerFunc(t *testing.T) {
+	var mutex sync.Mutex
+	truncatedBytes := 0
+
+	e := NewExecutable("./test_helpers/large_echo.sh")
+	e.TruncatedOutputLoggerFunc = func(line string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		truncatedBytes += len(line) + 1
+	}
+
+	result, err := e.Run()
+	assert.NoError(t, err)
+	assert.Equal(t, 30000, len(result.Stdout))
+
+	// 50000 lines of 63 bytes each, minus what was logged as usual
+	mutex.Lock()
+	defer mutex.Unlock()
+	assert.Equal(t, 50000*63-30000, truncatedBytes)
+}
+
+func TestLineWriterOptions(t *testing.T) {
+	var mutex sync.Mutex
+	loggedLines := []string{}
+
+	e := NewVerboseExecutable("printf", func(line string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		loggedLines = append(loggedLines, line)
+	})
+	e.LineWriterOptions = []linewriter.Option{linewriter.WithPartialLineMarker(" [no newline]")}
+
+	result, err := e.Clone().Run("first\\nsecond")
+	assert.NoError(t, err)
+	assert.Equal(t, "first\nsecond", string(result.Stdout))
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	assert.Equal(t, []string{"first", "second [no newline]"}, loggedLines)
+}
@@ -0,0 +1,85 @@
+package executable
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func getNewExecutableForPTYStdinTests(path string) *Executable {
+	e := NewExecutable(path)
+	e.StdioHandlerFactory = NewPtyInPipesOutStdioHandler
+	return e
+}
+
+func TestStdinIsTerminalInPtyStdin(t *testing.T) {
+	e := getNewExecutableForPTYStdinTests("bash")
+
+	result, err := e.Run("-c", "[ -t 0 ] && echo stdin is a tty; [ -t 1 ] || echo stdout is not a tty")
+	assert.NoError(t, err)
+	assert.Equal(t, "stdin is a tty\nstdout is not a tty\n", string(result.Stdout))
+}
+
+func TestRunWithStdinInPtyStdin(t *testing.T) {
+	e := getNewExecutableForPTYStdinTests("grep")
+
+	result, err := e.RunWithStdin([]byte("has cat\n"), "-q", "cat")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.ExitCode)
+
+	result, err = e.RunWithStdin([]byte("only dog\n"), "-q", "cat")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.ExitCode)
+}
+
+func TestOutputCaptureInPtyStdin(t *testing.T) {
+	e := getNewExecutableForPTYStdinTests("./test_helpers/stdout_echo.sh")
+	result, err := e.Run("hey")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "hey\n", string(result.Stdout))
+
+	e = getNewExecutableForPTYStdinTests("./test_helpers/stderr_echo.sh")
+	result, err = e.Run("hey")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "hey\n", string(result.Stderr))
+}
+
+func TestCloneKeepsStdioHandlerFactory(t *testing.T) {
+	e := getNewExecutableForPTYStdinTests("./test_helpers/stdout_echo.sh").Clone()
+	result, err := e.Run("hey")
+
+	assert.NoError(t, err)
+	assert.NotNil(t, e.StdioHandlerFactory)
+	assert.Equal(t, "hey\n", string(result.Stdout))
+}
+
+func TestRunWithStdinWithoutTrailingNewlineInPtyStdin(t *testing.T) {
+	e := getNewExecutableForPTYStdinTests("cat")
+	e.TimeoutInMilliseconds = 2000
+
+	result, err := e.RunWithStdin([]byte("first line\nabc"))
+	assert.NoError(t, err)
+	assert.Equal(t, "first line\nabc", string(result.Stdout))
+}
+
+func TestRunWithStdinWithLongLinesInPtyStdin(t *testing.T) {
+	e := getNewExecutableForPTYStdinTests("wc")
+	e.TimeoutInMilliseconds = 2000
+
+	longLine := strings.Repeat("a", 10000)
+
+	result, err := e.RunWithStdin([]byte(longLine+"\n"), "-c")
+	assert.NoError(t, err)
+	assert.Equal(t, "10001", strings.TrimSpace(string(result.Stdout)))
+
+	e = getNewExecutableForPTYStdinTests("cat")
+	e.TimeoutInMilliseconds = 2000
+
+	input := longLine + "\n" + strings.Repeat("b", ptyMaxLineLength) + "\n" + longLine
+	result, err = e.RunWithStdin([]byte(input))
+	assert.NoError(t, err)
+	assert.Equal(t, input, string(result.Stdout))
+}
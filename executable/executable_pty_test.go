@@ -1,6 +1,7 @@
 package executable
 
 import (
+	"io"
 	"os"
 	"testing"
 	"time"
@@ -263,3 +264,21 @@ func TestPathResolutionWithDifferentWorkingDirInPty(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "test-message\r\n", string(result.Stdout))
 }
+
+func TestStdoutSocketPairInPty(t *testing.T) {
+	parentEnd, childEnd, err := NewSocketPair()
+	assert.NoError(t, err)
+	defer parentEnd.Close()
+
+	e := getNewExecutableForPTYTests("./test_helpers/stdout_echo.sh")
+	e.StdoutFile = childEnd
+
+	result, err := e.Run("hey")
+	childEnd.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, "", string(result.Stdout))
+
+	output, err := io.ReadAll(parentEnd)
+	assert.NoError(t, err)
+	assert.Equal(t, "hey\n", string(output))
+}
@@ -0,0 +1,118 @@
+package executable
+
+import (
+	"bytes"
+	"os"
+	"sync"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// ptyMaxLineLength is the longest line (excluding the newline) that a PTY in canonical mode accepts, longer lines are
+	// truncated by the line discipline
+	ptyMaxLineLength = 4095
+
+	// ptyEOF is the VEOF character. It sends the pending line to the program without a newline, or EOF if no line is
+	// pending.
+	ptyEOF = 0x04
+)
+
+// ptyStdinWriter writes to the master end of a stdin PTY in canonical mode.
+//
+// Long lines are pushed to the program in chunks (using VEOF) so that the line discipline doesn't truncate them, and
+// the length of the pending line is tracked so that EOF can be sent even if the input doesn't end with a newline.
+type ptyStdinWriter struct {
+	master *os.File
+
+	// pendingLineLength is the number of bytes written since the last newline (or VEOF)
+	pendingLineLength int
+
+	mutex sync.Mutex
+}
+
+func (w *ptyStdinWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	written := 0
+
+	for len(p) > 0 {
+		room := ptyMaxLineLength - w.pendingLineLength
+
+		if newlineIndex := bytes.IndexByte(p, '\n'); newlineIndex >= 0 && newlineIndex <= room {
+			n, err := w.master.Write(p[:newlineIndex+1])
+			written += n
+			if err != nil {
+				return written, err
+			}
+
+			w.pendingLineLength = 0
+			p = p[newlineIndex+1:]
+		} else if len(p) <= room {
+			n, err := w.master.Write(p)
+			written += n
+			w.pendingLineLength += n
+
+			return written, err
+		} else {
+			n, err := w.master.Write(p[:room])
+			written += n
+			if err != nil {
+				return written, err
+			}
+
+			if err := w.pushPendingLine(); err != nil {
+				return written, err
+			}
+
+			p = p[room:]
+		}
+	}
+
+	return written, nil
+}
+
+// WriteEOF makes the program's next read return EOF (once it has read everything written so far)
+func (w *ptyStdinWriter) WriteEOF() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	// VEOF only means EOF at the start of a line
+	if w.pendingLineLength > 0 {
+		if err := w.pushPendingLine(); err != nil {
+			return err
+		}
+	}
+
+	_, err := w.master.Write([]byte{ptyEOF})
+	return err
+}
+
+func (w *ptyStdinWriter) Close() error {
+	return w.master.Close()
+}
+
+// pushPendingLine sends the pending line to the program without a newline
+func (w *ptyStdinWriter) pushPendingLine() error {
+	if _, err := w.master.Write([]byte{ptyEOF}); err != nil {
+		return err
+	}
+
+	w.pendingLineLength = 0
+
+	return nil
+}
+
+// disableEcho stops a PTY from echoing input back. Nothing reads a stdin PTY's output, so echoed input would otherwise
+// pile up until the PTY blocks.
+func disableEcho(f *os.File) error {
+	termios, err := unix.IoctlGetTermios(int(f.Fd()), ioctlGetTermios)
+	if err != nil {
+		return err
+	}
+
+	termios.Lflag &^= unix.ECHO | unix.ECHONL
+
+	return unix.IoctlSetTermios(int(f.Fd()), ioctlSetTermios, termios)
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package executable
+
+import "golang.org/x/sys/unix"
+
+const (
+	ioctlGetTermios = unix.TIOCGETA
+	ioctlSetTermios = unix.TIOCSETA
+)
@@ -0,0 +1,22 @@
+package executable
+
+import (
+	"os"
+	"syscall"
+)
+
+// NewSocketPair returns a pair of connected Unix domain sockets.
+//
+// One end can be passed to an Executable (via StdinFile, StdoutFile or ExtraFiles) and the other can be used by the
+// tester to talk to the program. Both ends must be closed by the caller.
+func NewSocketPair() (parentEnd *os.File, childEnd *os.File, err error) {
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_STREAM, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	syscall.CloseOnExec(fds[0])
+	syscall.CloseOnExec(fds[1])
+
+	return os.NewFile(uintptr(fds[0]), "socketpair-parent"), os.NewFile(uintptr(fds[1]), "socketpair-child"), nil
+}
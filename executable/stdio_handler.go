@@ -8,17 +8,21 @@ import (
 	"github.com/creack/pty"
 )
 
-type stdioHandler interface {
-	// GetStdin returns stdin on the parent's end
+// StdioHandler sets up and owns the standard streams of an Executable's process.
+//
+// A new StdioHandler is used for every run, so implementations don't need to be reusable. Custom implementations can be
+// plugged in using Executable.StdioHandlerFactory.
+type StdioHandler interface {
+	// GetStdin returns stdin on the parent's end (nil if the caller wired stdin to a file)
 	GetStdin() io.WriteCloser
 
-	// GetStdout returns stdout on the parent's end
+	// GetStdout returns stdout on the parent's end (nil if the caller wired stdout to a file)
 	GetStdout() io.ReadCloser
 
 	// GetStderr returns stderr on the parent's end
 	GetStderr() io.ReadCloser
 
-	// SetupStreams sets up child process' stdio streams
+	// SetupStreams sets up child process' stdio streams. Streams that are already set on cmd are left untouched.
 	SetupStreams(cmd *exec.Cmd) error
 
 	// CloseChildStreams closes the FDs duplicated for child (called after cmd.Start())
@@ -31,6 +35,21 @@ type stdioHandler interface {
 	TerminateStdin() error
 }
 
+// NewPipeTrioStdioHandler returns a StdioHandler that uses pipes for stdin, stdout and stderr. This is the default.
+func NewPipeTrioStdioHandler() StdioHandler {
+	return &pipeTrioStdioHandler{}
+}
+
+// NewPipeInPtysOutStdioHandler returns a StdioHandler that uses a pipe for stdin and PTY devices for stdout and stderr.
+func NewPipeInPtysOutStdioHandler() StdioHandler {
+	return &pipeInPtysOutStdioHandler{}
+}
+
+// NewPtyInPipesOutStdioHandler returns a StdioHandler that uses a PTY device for stdin and pipes for stdout and stderr.
+func NewPtyInPipesOutStdioHandler() StdioHandler {
+	return &ptyInPipesOutStdioHandler{}
+}
+
 // pipeTrioStdioHandler deals with pipe based i/o
 type pipeTrioStdioHandler struct {
 	stdinPipe  io.WriteCloser
@@ -53,18 +72,22 @@ func (h *pipeTrioStdioHandler) GetStderr() io.ReadCloser {
 func (h *pipeTrioStdioHandler) SetupStreams(cmd *exec.Cmd) error {
 	var err error
 
-	if h.stdinPipe, err = cmd.StdinPipe(); err != nil {
-		return err
+	if cmd.Stdin == nil {
+		if h.stdinPipe, err = cmd.StdinPipe(); err != nil {
+			return err
+		}
 	}
 
-	if h.stdoutPipe, err = cmd.StdoutPipe(); err != nil {
-		h.stdinPipe.Close()
-		return err
+	if cmd.Stdout == nil {
+		if h.stdoutPipe, err = cmd.StdoutPipe(); err != nil {
+			closeIfOpen(h.stdinPipe)
+			return err
+		}
 	}
 
 	if h.stderrPipe, err = cmd.StderrPipe(); err != nil {
-		h.stdinPipe.Close()
-		h.stdoutPipe.Close()
+		closeIfOpen(h.stdinPipe)
+		closeIfOpen(h.stdoutPipe)
 		return err
 	}
 
@@ -81,7 +104,7 @@ func (h *pipeTrioStdioHandler) CloseParentStreams() error {
 }
 
 func (h *pipeTrioStdioHandler) TerminateStdin() error {
-	if err := h.stdinPipe.Close(); err != nil {
+	if err := closeIfOpen(h.stdinPipe); err != nil {
 		return err
 	}
 
@@ -101,6 +124,11 @@ func (h *pipeInPtysOutStdioHandler) GetStdin() io.WriteCloser {
 }
 
 func (h *pipeInPtysOutStdioHandler) GetStdout() io.ReadCloser {
+	// Avoid returning a non-nil interface wrapping a nil *os.File
+	if h.stdoutMaster == nil {
+		return nil
+	}
+
 	return h.stdoutMaster
 }
 
@@ -109,19 +137,24 @@ func (h *pipeInPtysOutStdioHandler) GetStderr() io.ReadCloser {
 }
 
 func (h *pipeInPtysOutStdioHandler) SetupStreams(cmd *exec.Cmd) error {
-	if err := h.openAll(); err != nil {
+	if err := h.openAll(cmd.Stdout == nil); err != nil {
 		return err
 	}
 
-	var err error
-	h.stdinPipe, err = cmd.StdinPipe()
+	if cmd.Stdin == nil {
+		var err error
+		h.stdinPipe, err = cmd.StdinPipe()
 
-	if err != nil {
-		h.closeAll()
-		return err
+		if err != nil {
+			h.closeAll()
+			return err
+		}
+	}
+
+	if h.stdoutSlave != nil {
+		cmd.Stdout = h.stdoutSlave
 	}
 
-	cmd.Stdout = h.stdoutSlave
 	cmd.Stderr = h.stderrSlave
 
 	return nil
@@ -142,18 +175,20 @@ func (h *pipeInPtysOutStdioHandler) CloseParentStreams() error {
 }
 
 func (h *pipeInPtysOutStdioHandler) TerminateStdin() error {
-	return h.stdinPipe.Close()
+	return closeIfOpen(h.stdinPipe)
 }
 
-// openAll attempts to open all PTY pairs.
+// openAll attempts to open all PTY pairs (the stdout pair is skipped if shouldOpenStdout is false).
 // Returns an error if any PTY fails to open, and automatically cleans up any successfully opened PTYs.
-func (r *pipeInPtysOutStdioHandler) openAll() error {
+func (r *pipeInPtysOutStdioHandler) openAll(shouldOpenStdout bool) error {
 	var err error
 
-	r.stdoutMaster, r.stdoutSlave, err = pty.Open()
-	if err != nil {
-		r.closeAll()
-		return err
+	if shouldOpenStdout {
+		r.stdoutMaster, r.stdoutSlave, err = pty.Open()
+		if err != nil {
+			r.closeAll()
+			return err
+		}
 	}
 
 	r.stderrMaster, r.stderrSlave, err = pty.Open()
@@ -192,3 +227,86 @@ func (r *pipeInPtysOutStdioHandler) closeSlaves() error {
 func (r *pipeInPtysOutStdioHandler) closeMasters() error {
 	return closeAllWithCloserFunc(closeIfNotNil, r.stdoutMaster, r.stderrMaster)
 }
+
+// ptyInPipesOutStdioHandler uses a PTY device for stdin and pipes for stdout and stderr
+// Useful for programs that behave differently when stdin is a terminal (like REPLs)
+type ptyInPipesOutStdioHandler struct {
+	stdinMaster, stdinSlave *os.File
+	stdinWriter             *ptyStdinWriter
+	stdoutPipe              io.ReadCloser
+	stderrPipe              io.ReadCloser
+}
+
+func (h *ptyInPipesOutStdioHandler) GetStdin() io.WriteCloser {
+	// Avoid returning a non-nil interface wrapping a nil *ptyStdinWriter
+	if h.stdinWriter == nil {
+		return nil
+	}
+
+	return h.stdinWriter
+}
+
+func (h *ptyInPipesOutStdioHandler) GetStdout() io.ReadCloser {
+	return h.stdoutPipe
+}
+
+func (h *ptyInPipesOutStdioHandler) GetStderr() io.ReadCloser {
+	return h.stderrPipe
+}
+
+func (h *ptyInPipesOutStdioHandler) SetupStreams(cmd *exec.Cmd) error {
+	var err error
+
+	if cmd.Stdin == nil {
+		if h.stdinMaster, h.stdinSlave, err = pty.Open(); err != nil {
+			return err
+		}
+
+		if err = disableEcho(h.stdinSlave); err != nil {
+			closeAllWithCloserFunc(closeIfNotNil, h.stdinMaster, h.stdinSlave)
+			return err
+		}
+
+		h.stdinWriter = &ptyStdinWriter{master: h.stdinMaster}
+		cmd.Stdin = h.stdinSlave
+	}
+
+	if cmd.Stdout == nil {
+		if h.stdoutPipe, err = cmd.StdoutPipe(); err != nil {
+			closeAllWithCloserFunc(closeIfNotNil, h.stdinMaster, h.stdinSlave)
+			return err
+		}
+	}
+
+	if h.stderrPipe, err = cmd.StderrPipe(); err != nil {
+		closeAllWithCloserFunc(closeIfNotNil, h.stdinMaster, h.stdinSlave)
+		closeIfOpen(h.stdoutPipe)
+		return err
+	}
+
+	return nil
+}
+
+func (h *ptyInPipesOutStdioHandler) CloseChildStreams() error {
+	// Close slave end - child process now owns it. Pipes are handled by the exec library.
+	return closeIfNotNil(h.stdinSlave)
+}
+
+func (h *ptyInPipesOutStdioHandler) CloseParentStreams() error {
+	masterCloseError := closeIfNotNil(h.stdinMaster)
+	pipesCloseError := closeAllWithCloserFunc(closeIfOpen, h.stdoutPipe, h.stderrPipe)
+	if masterCloseError != nil {
+		return masterCloseError
+	}
+	return pipesCloseError
+}
+
+func (h *ptyInPipesOutStdioHandler) TerminateStdin() error {
+	if h.stdinWriter == nil {
+		return nil
+	}
+
+	// Closing the master end would hang up the terminal (discarding unread input), send EOF (Ctrl-D) instead.
+	// The master end itself is closed in CloseParentStreams.
+	return h.stdinWriter.WriteEOF()
+}
@@ -48,6 +48,10 @@ func isTTY(o any) bool {
 
 // closeIfNotNil closes an io.Closer if it is not already closed
 func closeIfNotNil(c io.Closer) error {
+	if c == nil {
+		return nil
+	}
+
 	v := reflect.ValueOf(c)
 
 	if v.Kind() == reflect.Pointer && v.IsNil() {
@@ -59,6 +63,10 @@ func closeIfNotNil(c io.Closer) error {
 
 // closeIfOpen closes an io.Closer if it is not already closed
 func closeIfOpen(c io.Closer) error {
+	if c == nil {
+		return nil
+	}
+
 	err := c.Close()
 
 	if err != nil && !errors.Is(err, os.ErrClosed) {
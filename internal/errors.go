@@ -1,5 +1,10 @@
 package internal
 
+import (
+	"fmt"
+	"time"
+)
+
 type UserError struct {
 	Message string
 }
@@ -7,3 +12,25 @@ type UserError struct {
 func (e *UserError) Error() string {
 	return e.Message
 }
+
+// PanicError is returned when tester code panics. It indicates a bug in the tester, not in the user's code.
+type PanicError struct {
+	// Value is the value that was passed to panic()
+	Value any
+
+	// Stack is the stack trace of the goroutine that panicked
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("CodeCrafters internal error. Test function panicked: %v", e.Value)
+}
+
+// TimeoutError is returned when a test function doesn't return within its timeout
+type TimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("timed out, test exceeded %d seconds", int64(e.Timeout.Seconds()))
+}
@@ -2,103 +2,229 @@ package linewriter
 
 import (
 	"bytes"
+	"fmt"
 	"io"
+	"sync"
 	"time"
+	"unicode/utf8"
 )
 
+// LineWriter buffers writes and passes them on to the underlying writer one line at a time.
+//
+// If a partial line (one without a trailing newline) isn't completed within the timeout, it is flushed with a
+// newline appended.
 type LineWriter struct {
-	buffer           chan byte
-	flushableStrings chan string
-	writer           io.Writer
-	timeout          time.Duration
+	writer  io.Writer
+	timeout time.Duration
+
+	// maxLineLength is the maximum length (in bytes, excluding the newline) of lines passed to the underlying writer.
+	// Zero means no limit.
+	maxLineLength int
+
+	// shouldTruncateLongLines controls whether lines over maxLineLength are truncated (true) or wrapped (false)
+	shouldTruncateLongLines bool
+
+	// partialLineMarker is appended to lines that were flushed without a newline being received
+	partialLineMarker string
+
+	// mutex guards all fields below, and serializes writes to the underlying writer
+	mutex sync.Mutex
+
+	// partialLine holds bytes received after the last newline
+	partialLine []byte
+
+	// truncatedByteCount is the number of bytes dropped from partialLine because it exceeded maxLineLength
+	truncatedByteCount int
+
+	// partialLineDeadline is when partialLine will be flushed if no newline arrives
+	partialLineDeadline time.Time
+
+	partialLineTimer *time.Timer
 	lastErr          error
-	flushChan        chan bool
-	flushedChan      chan bool
+}
+
+// Option configures optional LineWriter behaviour
+type Option func(*LineWriter)
+
+// WithWrappedLines splits lines longer than maxLength bytes into multiple lines.
+func WithWrappedLines(maxLength int) Option {
+	return func(w *LineWriter) {
+		w.maxLineLength = maxLength
+		w.shouldTruncateLongLines = false
+	}
+}
+
+// WithTruncatedLines cuts lines longer than maxLength bytes short, noting how many bytes were dropped.
+func WithTruncatedLines(maxLength int) Option {
+	return func(w *LineWriter) {
+		w.maxLineLength = maxLength
+		w.shouldTruncateLongLines = true
+	}
+}
+
+// WithPartialLineMarker appends marker to lines that are emitted because of the timeout (or Flush) rather than
+// because a newline was received. Example: " [no newline]"
+func WithPartialLineMarker(marker string) Option {
+	return func(w *LineWriter) {
+		w.partialLineMarker = marker
+	}
 }
 
 // Write queues a string for writing
-func (w *LineWriter) Write(bytes []byte) (n int, err error) {
-	for _, byte := range bytes {
-		w.buffer <- byte
+func (w *LineWriter) Write(p []byte) (n int, err error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	remaining := p
+
+	for {
+		newlineIndex := bytes.IndexByte(remaining, '\n')
+		if newlineIndex == -1 {
+			w.appendToPartialLine(remaining)
+			break
+		}
+
+		w.appendToPartialLine(remaining[:newlineIndex])
+		w.emitPartialLine(true)
+		remaining = remaining[newlineIndex+1:]
 	}
 
-	return len(bytes), nil
+	if w.hasPartialLine() {
+		w.schedulePartialLineFlush()
+	}
+
+	return len(p), nil
 }
 
 // Flush flushes any pending strings, and returns an error if any writes failed
 // in the past
 func (w *LineWriter) Flush() (err error) {
-	w.flushChan <- true
-	<-w.flushedChan
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.partialLineTimer != nil {
+		w.partialLineTimer.Stop()
+	}
+
+	if w.hasPartialLine() {
+		w.emitPartialLine(false)
+	}
+
 	return w.lastErr
 }
 
 // New returns a LineWriter instance
-func New(w io.Writer, timeout time.Duration) *LineWriter {
+func New(w io.Writer, timeout time.Duration, options ...Option) *LineWriter {
 	lw := &LineWriter{
-		buffer:           make(chan byte),
-		flushableStrings: make(chan string),
-		writer:           w,
-		timeout:          timeout,
-		flushChan:        make(chan bool),
-		flushedChan:      make(chan bool),
-	}
-	lw.startReader()
-	lw.startWriter()
+		writer:  w,
+		timeout: timeout,
+	}
+
+	for _, option := range options {
+		option(lw)
+	}
+
 	return lw
 }
 
-func (w *LineWriter) startReader() {
-	go func(w *LineWriter) {
-		accumulated := bytes.NewBuffer([]byte{})
-
-	loop:
-		for {
-			select {
-			case b := <-w.buffer:
-				accumulated.WriteByte(b)
-
-				if string(b) == "\n" {
-					w.flushableStrings <- accumulated.String()
-					accumulated = bytes.NewBuffer([]byte{})
-				}
-			case <-time.After(w.timeout):
-				if accumulated.Len() > 0 {
-					accumulated.WriteByte('\n')
-					w.flushableStrings <- accumulated.String()
-					accumulated = bytes.NewBuffer([]byte{})
-				}
-			case <-w.flushChan:
-				if accumulated.Len() > 0 {
-					accumulated.WriteByte('\n')
-					w.flushableStrings <- accumulated.String()
-					accumulated = bytes.NewBuffer([]byte{})
-				}
-				close(w.flushableStrings)
-				break loop
-			}
+// schedulePartialLineFlush (re)starts the partial line timeout, must be called with the mutex held
+func (w *LineWriter) schedulePartialLineFlush() {
+	w.partialLineDeadline = time.Now().Add(w.timeout)
+
+	if w.partialLineTimer == nil {
+		w.partialLineTimer = time.AfterFunc(w.timeout, w.onPartialLineTimeout)
+	} else {
+		w.partialLineTimer.Reset(w.timeout)
+	}
+}
+
+func (w *LineWriter) onPartialLineTimeout() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	// A write might have pushed the deadline while we were waiting for the mutex
+	if remaining := time.Until(w.partialLineDeadline); remaining > 0 {
+		w.partialLineTimer.Reset(remaining)
+		return
+	}
+
+	if w.hasPartialLine() {
+		w.emitPartialLine(false)
+	}
+}
+
+// hasPartialLine returns true if there are bytes waiting for a newline, must be called with the mutex held
+func (w *LineWriter) hasPartialLine() bool {
+	return len(w.partialLine) > 0 || w.truncatedByteCount > 0
+}
+
+// appendToPartialLine adds bytes (that don't contain a newline) to partialLine, applying the line length limit.
+// Must be called with the mutex held.
+func (w *LineWriter) appendToPartialLine(segment []byte) {
+	if w.maxLineLength <= 0 {
+		w.partialLine = append(w.partialLine, segment...)
+		return
+	}
+
+	if w.shouldTruncateLongLines {
+		if w.truncatedByteCount > 0 {
+			w.truncatedByteCount += len(segment)
+			return
 		}
-	}(w)
+
+		w.partialLine = append(w.partialLine, segment...)
+
+		if len(w.partialLine) > w.maxLineLength {
+			cutIndex := runeBoundaryBefore(w.partialLine, w.maxLineLength)
+			w.truncatedByteCount = len(w.partialLine) - cutIndex
+			w.partialLine = w.partialLine[:cutIndex]
+		}
+
+		return
+	}
+
+	w.partialLine = append(w.partialLine, segment...)
+
+	for len(w.partialLine) > w.maxLineLength {
+		cutIndex := runeBoundaryBefore(w.partialLine, w.maxLineLength)
+		w.writeLine(append(w.partialLine[:cutIndex:cutIndex], '\n'))
+		w.partialLine = append(w.partialLine[:0], w.partialLine[cutIndex:]...)
+	}
 }
 
-func (w *LineWriter) startWriter() {
-	go func(w *LineWriter) {
-	loop:
-		for {
-			select {
-			case str, more := <-w.flushableStrings:
-				if str != "" {
-					_, err := w.writer.Write([]byte(str))
-					if err != nil {
-						w.lastErr = err
-					}
-				}
-
-				if !more {
-					w.flushedChan <- true
-					break loop
-				}
-			}
+// emitPartialLine writes out partialLine with a newline appended, must be called with the mutex held
+func (w *LineWriter) emitPartialLine(isNewlineReceived bool) {
+	line := w.partialLine
+
+	if w.truncatedByteCount > 0 {
+		line = fmt.Appendf(line, "... (%d bytes truncated)", w.truncatedByteCount)
+	}
+
+	if !isNewlineReceived {
+		line = append(line, w.partialLineMarker...)
+	}
+
+	line = append(line, '\n')
+	w.writeLine(line)
+
+	w.partialLine = line[:0]
+	w.truncatedByteCount = 0
+}
+
+// writeLine writes a single line to the underlying writer, must be called with the mutex held
+func (w *LineWriter) writeLine(line []byte) {
+	if _, err := w.writer.Write(line); err != nil {
+		w.lastErr = err
+	}
+}
+
+// runeBoundaryBefore returns the largest index <= maxIndex that doesn't split a UTF-8 sequence
+func runeBoundaryBefore(b []byte, maxIndex int) int {
+	for index := maxIndex; index > 0 && maxIndex-index < utf8.UTFMax; index-- {
+		if utf8.RuneStart(b[index]) {
+			return index
 		}
-	}(w)
+	}
+
+	return maxIndex
 }
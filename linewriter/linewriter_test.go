@@ -2,6 +2,8 @@ package linewriter
 
 import (
 	"bytes"
+	"errors"
+	"io"
 	"testing"
 	"time"
 
@@ -74,3 +76,154 @@ func TestHandlesTimeout(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+func TestWritesLinesSplitAcrossWrites(t *testing.T) {
+	w := &lineRecorder{}
+	lw := New(w, 100*time.Millisecond)
+
+	lw.Write([]byte("ab"))
+	lw.Write([]byte("c\nde"))
+	lw.Write([]byte("f\ng\n"))
+
+	err := lw.Flush()
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	if !assert.Equal(t, []string{"abc\n", "def\n", "g\n"}, w.lines) {
+		t.FailNow()
+	}
+}
+
+func TestFlushesPartialLine(t *testing.T) {
+	w := bytes.NewBuffer([]byte{})
+	lw := New(w, 100*time.Millisecond)
+	lw.Write([]byte("abc"))
+
+	err := lw.Flush()
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	if !assert.Equal(t, "abc\n", w.String()) {
+		t.FailNow()
+	}
+
+	// The timeout shouldn't flush anything more after an explicit flush
+	time.Sleep(200 * time.Millisecond)
+
+	if !assert.Equal(t, "abc\n", w.String()) {
+		t.FailNow()
+	}
+}
+
+func TestFlushReturnsLastError(t *testing.T) {
+	lw := New(failingWriter{}, 100*time.Millisecond)
+	lw.Write([]byte("abc\n"))
+
+	err := lw.Flush()
+	assert.EqualError(t, err, "write failed")
+}
+
+func TestWrapsLongLines(t *testing.T) {
+	w := &lineRecorder{}
+	lw := New(w, 100*time.Millisecond, WithWrappedLines(4))
+	lw.Write([]byte("abcdefghij\nabcd\n"))
+	lw.Flush()
+
+	assert.Equal(t, []string{"abcd\n", "efgh\n", "ij\n", "abcd\n"}, w.lines)
+}
+
+func TestWrapsLongLinesOnRuneBoundaries(t *testing.T) {
+	w := &lineRecorder{}
+	lw := New(w, 100*time.Millisecond, WithWrappedLines(4))
+	lw.Write([]byte("abcé\n"))
+	lw.Flush()
+
+	assert.Equal(t, []string{"abc\n", "é\n"}, w.lines)
+}
+
+func TestTruncatesLongLines(t *testing.T) {
+	w := &lineRecorder{}
+	lw := New(w, 100*time.Millisecond, WithTruncatedLines(4))
+	lw.Write([]byte("abcdef"))
+	lw.Write([]byte("ghij\nabcd\n"))
+	lw.Flush()
+
+	assert.Equal(t, []string{"abcd... (6 bytes truncated)\n", "abcd\n"}, w.lines)
+}
+
+func TestMarksPartialLines(t *testing.T) {
+	w := bytes.NewBuffer([]byte{})
+	lw := New(w, 100*time.Millisecond, WithPartialLineMarker(" [no newline]"))
+	lw.Write([]byte("abc\ndef"))
+
+	time.Sleep(200 * time.Millisecond)
+
+	lw.Write([]byte("ghi"))
+	lw.Flush()
+
+	assert.Equal(t, "abc\ndef [no newline]\nghi [no newline]\n", w.String())
+}
+
+func BenchmarkWriteShortLines(b *testing.B) {
+	lw := New(io.Discard, 500*time.Millisecond)
+	line := []byte("Welcome - this is a long long line with a long sentence in it.\n")
+
+	b.SetBytes(int64(len(line)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		lw.Write(line)
+	}
+
+	lw.Flush()
+}
+
+func BenchmarkWriteLargeChunks(b *testing.B) {
+	lw := New(io.Discard, 500*time.Millisecond)
+	chunk := bytes.Repeat([]byte("Welcome - this is a long long line with a long sentence in it.\n"), 512)
+
+	b.SetBytes(int64(len(chunk)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		lw.Write(chunk)
+	}
+
+	lw.Flush()
+}
+
+func BenchmarkWritePartialLines(b *testing.B) {
+	lw := New(io.Discard, 500*time.Millisecond)
+	chunk := []byte("a partial line without a newline, ")
+
+	b.SetBytes(int64(len(chunk)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		lw.Write(chunk)
+
+		if i%100 == 99 {
+			lw.Write([]byte{'\n'})
+		}
+	}
+
+	lw.Flush()
+}
+
+// lineRecorder records each call to Write separately
+type lineRecorder struct {
+	lines []string
+}
+
+func (r *lineRecorder) Write(p []byte) (int, error) {
+	r.lines = append(r.lines, string(p))
+	return len(p), nil
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
@@ -0,0 +1,30 @@
+package logger
+
+import (
+	"bytes"
+	"log"
+)
+
+// WithBufferedOutput returns a clone of the logger whose output (and that of its clones) is held back until the
+// returned flush func is called. Useful for keeping the output of concurrently running tests in a deterministic order.
+//
+// Lines written to the full log (see SetDefaultFullLogWriter) aren't buffered.
+func (l *Logger) WithBufferedOutput() (*Logger, func()) {
+	buffer := &bytes.Buffer{}
+
+	cloned := l.Clone()
+	cloned.writer = buffer
+	cloned.logger = *log.New(syncWriter{writer: buffer}, "", 0)
+	cloned.updateLoggerPrefix()
+
+	flush := func() {
+		globalLogMutex.Lock()
+		bufferedBytes := bytes.Clone(buffer.Bytes())
+		buffer.Reset()
+		globalLogMutex.Unlock()
+
+		syncWriter{writer: l.writer}.Write(bufferedBytes)
+	}
+
+	return cloned, flush
+}
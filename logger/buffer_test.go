@@ -0,0 +1,32 @@
+package logger
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/codecrafters-io/tester-utils/color_policy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithBufferedOutput(t *testing.T) {
+	color_policy.Set(color_policy.NeverColorPolicy)
+	defer color_policy.Set(color_policy.DefaultColorPolicy)
+
+	output := bytes.NewBuffer([]byte{})
+	l := GetLoggerWithWriter(output, false, "[test] ")
+
+	firstLogger, flushFirst := l.WithBufferedOutput()
+	secondLogger, flushSecond := l.WithBufferedOutput()
+
+	secondLogger.Infof("second")
+	firstLogger.Clone().Infof("first")
+	assert.Equal(t, "", output.String())
+
+	flushFirst()
+	flushSecond()
+	assert.Equal(t, "[test] first\n[test] second\n", output.String())
+
+	// Flushing again doesn't repeat lines
+	flushFirst()
+	assert.Equal(t, "[test] first\n[test] second\n", output.String())
+}
@@ -0,0 +1,146 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// LineKind tells apart lines with the same Level, like Infof and Successf lines
+type LineKind string
+
+const (
+	// RegularLineKind is used for Debugf, Infof, Warnf, Errorf & Criticalf lines
+	RegularLineKind LineKind = "regular"
+
+	// SuccessLineKind is used for Successf lines (with InfoLevel)
+	SuccessLineKind LineKind = "success"
+
+	// PlainLineKind is used for Plainf lines. They aren't filtered by level, but are recorded with InfoLevel.
+	PlainLineKind LineKind = "plain"
+
+	// ProgramOutputLineKind is used for output from the user's program (see ProgramOutputln), recorded with InfoLevel
+	ProgramOutputLineKind LineKind = "program_output"
+)
+
+// CapturedLine is a line recorded by a capturing logger (see GetCapturingLogger)
+type CapturedLine struct {
+	// Level is the line's severity, use it to filter lines
+	Level Level
+
+	// Kind tells apart lines with the same Level
+	Kind LineKind
+
+	// Prefix is the logger's primary prefix. Example: "[tester::#1] "
+	Prefix string
+
+	// SecondaryPrefixes is the logger's secondary prefix stack at the time of logging
+	SecondaryPrefixes []string
+
+	// Message is the logged line (without colors or prefixes)
+	Message string
+}
+
+// lineCapture holds lines recorded by a capturing logger, shared between clones
+type lineCapture struct {
+	mutex sync.Mutex
+	lines []CapturedLine
+}
+
+func (c *lineCapture) record(line CapturedLine) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.lines = append(c.lines, line)
+}
+
+// GetCapturingLogger returns a logger that records every emitted line in memory instead of printing it. Useful for
+// unit testing test functions.
+//
+// Level filtering works as usual: lines that wouldn't be printed aren't recorded. Clones share the same recording.
+func GetCapturingLogger(isDebug bool, prefix string) *Logger {
+	return GetLoggerWithWriter(io.Discard, isDebug, prefix).WithLineCapture()
+}
+
+// WithLineCapture returns a clone of the logger that records emitted lines (see GetCapturedLines) while still writing
+// them as usual. Clones made from it share the same recording.
+func (l *Logger) WithLineCapture() *Logger {
+	cloned := l.Clone()
+	cloned.capture = &lineCapture{}
+
+	return cloned
+}
+
+// GetCapturedLines returns all lines recorded so far. Returns nil if this isn't a capturing logger.
+func (l *Logger) GetCapturedLines() []CapturedLine {
+	if l.capture == nil {
+		return nil
+	}
+
+	l.capture.mutex.Lock()
+	defer l.capture.mutex.Unlock()
+
+	lines := make([]CapturedLine, len(l.capture.lines))
+	copy(lines, l.capture.lines)
+
+	return lines
+}
+
+// GetCapturedMessages returns the messages of all lines recorded so far
+func (l *Logger) GetCapturedMessages() []string {
+	messages := []string{}
+
+	for _, line := range l.GetCapturedLines() {
+		messages = append(messages, line.Message)
+	}
+
+	return messages
+}
+
+// GetEventLevel returns the level used for the line in JSON events. Example: "success"
+func (c CapturedLine) GetEventLevel() string {
+	if c.Kind == RegularLineKind {
+		return c.Level.String()
+	}
+
+	return string(c.Kind)
+}
+
+// captureLine records a line if this is a capturing logger. eventLevel is the level used in JSON events.
+func (l *Logger) captureLine(eventLevel string, message string) {
+	if l.capture == nil {
+		return
+	}
+
+	level, kind := parseEventLevel(eventLevel)
+
+	secondaryPrefixesCopy := make([]string, len(l.secondaryPrefixes))
+	copy(secondaryPrefixesCopy, l.secondaryPrefixes)
+
+	l.capture.record(CapturedLine{
+		Level:             level,
+		Kind:              kind,
+		Prefix:            l.prefix,
+		SecondaryPrefixes: secondaryPrefixesCopy,
+		Message:           message,
+	})
+}
+
+// parseEventLevel converts a JSON event level (like "success") into a Level & LineKind
+func parseEventLevel(eventLevel string) (Level, LineKind) {
+	switch eventLevel {
+	case string(SuccessLineKind):
+		return InfoLevel, SuccessLineKind
+	case string(PlainLineKind):
+		return InfoLevel, PlainLineKind
+	case string(ProgramOutputLineKind):
+		return InfoLevel, ProgramOutputLineKind
+	}
+
+	level, err := ParseLevel(eventLevel)
+	if err != nil {
+		panic(fmt.Sprintf("CodeCrafters internal error: %s", err))
+	}
+
+	return level, RegularLineKind
+}
@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCapturingLogger(t *testing.T) {
+	l := GetCapturingLogger(false, "[tester::#1] ")
+
+	l.Debugf("not captured, debug mode is off")
+	l.Infof("Sending %q", "PING")
+
+	l.WithAdditionalSecondaryPrefix("client-1", func() {
+		l.Clone().Successf("Received: +PONG\nline two")
+	})
+
+	l.ProgramOutputln("server started")
+
+	assert.Equal(t, []CapturedLine{
+		{Level: InfoLevel, Kind: RegularLineKind, Prefix: "[tester::#1] ", SecondaryPrefixes: []string{}, Message: `Sending "PING"`},
+		{Level: InfoLevel, Kind: SuccessLineKind, Prefix: "[tester::#1] ", SecondaryPrefixes: []string{"client-1"}, Message: "Received: +PONG"},
+		{Level: InfoLevel, Kind: SuccessLineKind, Prefix: "[tester::#1] ", SecondaryPrefixes: []string{"client-1"}, Message: "line two"},
+		{Level: InfoLevel, Kind: ProgramOutputLineKind, Prefix: "[tester::#1] ", SecondaryPrefixes: []string{}, Message: "server started"},
+	}, l.GetCapturedLines())
+
+	assert.Equal(t, []string{`Sending "PING"`, "Received: +PONG", "line two", "server started"}, l.GetCapturedMessages())
+}
+
+func TestCapturedLineLevels(t *testing.T) {
+	l := GetCapturingLogger(false, "")
+
+	l.Warnf("slow response")
+	l.Plainln("plain")
+	l.Criticalf("cheating detected")
+
+	lines := l.GetCapturedLines()
+
+	// Lines can be filtered by severity
+	warningsOrWorse := []string{}
+	for _, line := range lines {
+		if line.Level >= WarnLevel {
+			warningsOrWorse = append(warningsOrWorse, line.Message)
+		}
+	}
+
+	assert.Equal(t, []string{"slow response", "cheating detected"}, warningsOrWorse)
+	assert.Equal(t, []string{"warn", "plain", "critical"}, []string{lines[0].GetEventLevel(), lines[1].GetEventLevel(), lines[2].GetEventLevel()})
+}
+
+func TestNonCapturingLogger(t *testing.T) {
+	l := GetLoggerWithWriter(io.Discard, false, "")
+	l.Infof("hello")
+
+	assert.Nil(t, l.GetCapturedLines())
+}
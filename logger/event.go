@@ -0,0 +1,76 @@
+package logger
+
+// OutputFormat controls how loggers render their output
+type OutputFormat string
+
+const (
+	// TextOutputFormat renders colored, human-readable lines. This is the default.
+	TextOutputFormat OutputFormat = "text"
+
+	// JSONOutputFormat renders newline-delimited JSON events (see Event)
+	JSONOutputFormat OutputFormat = "json"
+)
+
+// Event types emitted in JSONOutputFormat
+const (
+	// LogEventType is emitted for every line logged by the tester
+	LogEventType = "log"
+
+	// ProgramOutputEventType is emitted for every line of output from the user's program
+	ProgramOutputEventType = "program_output"
+
+	// StageStartedEventType is emitted before a stage's test function is run
+	StageStartedEventType = "stage_started"
+
+	// StageFinishedEventType is emitted once a stage's result is known
+	StageFinishedEventType = "stage_finished"
+
+	// ResultEventType is emitted once, after all stages have been run
+	ResultEventType = "result"
+
+	// GroupStartedEventType is emitted when a log group is opened (see Logger.WithGroup). Message is the group's title.
+	GroupStartedEventType = "group_started"
+
+	// GroupFinishedEventType is emitted when a log group is closed. Message is the group's title.
+	GroupFinishedEventType = "group_finished"
+)
+
+// Event is a single line in the JSON event stream. Fields that don't apply to an event's type are omitted.
+type Event struct {
+	// Type is one of the *EventType constants
+	Type string `json:"type"`
+
+	// Level is the log level of a log event. Example: "info"
+	Level string `json:"level,omitempty"`
+
+	// Prefix is the logger's primary prefix, without brackets. Example: "tester::#1"
+	Prefix string `json:"prefix,omitempty"`
+
+	// SecondaryPrefixes is the logger's secondary prefix stack at the time of logging
+	SecondaryPrefixes []string `json:"secondary_prefixes,omitempty"`
+
+	// Groups is the stack of open log groups (outermost first) at the time of logging
+	Groups []string `json:"groups,omitempty"`
+
+	// Message is the logged line (without colors)
+	Message string `json:"message,omitempty"`
+
+	// StageSlug is the slug of the stage for stage events. Example: "bind-to-port"
+	StageSlug string `json:"stage_slug,omitempty"`
+
+	// StageTitle is the title of the stage for stage events. Example: "Stage #1: Bind to a port"
+	StageTitle string `json:"stage_title,omitempty"`
+
+	// Status is "passed" or "failed" for result events. For stage_finished events it can also be "skipped" (with the
+	// reason in Message) or "passed_with_warnings".
+	Status string `json:"status,omitempty"`
+
+	// Error is the failure message for stage_finished events
+	Error string `json:"error,omitempty"`
+
+	// Warnings are the warnings for stage_finished events with the "passed_with_warnings" status
+	Warnings []string `json:"warnings,omitempty"`
+
+	// DurationInMilliseconds is how long the stage took for stage_finished events
+	DurationInMilliseconds int64 `json:"duration_ms,omitempty"`
+}
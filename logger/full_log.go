@@ -0,0 +1,61 @@
+package logger
+
+import (
+	"io"
+	"strings"
+)
+
+// defaultFullLogWriter receives every line from loggers created while it's set, nil disables the full log
+var defaultFullLogWriter io.Writer
+
+// SetDefaultFullLogWriter sets a writer that loggers created after this call copy every line to, regardless of level.
+// Pass nil to disable.
+//
+// The full log is always plain text (no colors or timestamps), and includes debug logs & program output that was left
+// out of the console (see FullLogOnlyln). Lines that quiet loggers suppress aren't included, so anti-cheat details
+// stay hidden.
+func SetDefaultFullLogWriter(writer io.Writer) {
+	globalLogMutex.Lock()
+	defer globalLogMutex.Unlock()
+
+	defaultFullLogWriter = writer
+}
+
+// getDefaultFullLogWriter returns the full log writer that new loggers should use
+func getDefaultFullLogWriter() io.Writer {
+	globalLogMutex.Lock()
+	defer globalLogMutex.Unlock()
+
+	return defaultFullLogWriter
+}
+
+// FullLogOnlyln writes a line to the full log (see SetDefaultFullLogWriter) without printing it. Used for program
+// output that was truncated on the console.
+func (l *Logger) FullLogOnlyln(msg string) {
+	l.writeToFullLog(l.redact(msg))
+}
+
+// writeSuppressedToFullLog writes a message that was filtered out by the logger's level to the full log
+func (l *Logger) writeSuppressedToFullLog(fstring string, args ...any) {
+	if l.fullLogWriter == nil || l.IsQuiet {
+		return
+	}
+
+	l.writeToFullLog(l.redact(formatMessage(fstring, args...)))
+}
+
+// writeToFullLog writes an already redacted message to the full log, one line at a time
+func (l *Logger) writeToFullLog(msg string) {
+	if l.fullLogWriter == nil || l.isSilenced() {
+		return
+	}
+
+	prefix := l.getPlainPrefix() + l.getGroupIndentation()
+
+	var builder strings.Builder
+	for _, line := range strings.Split(msg, "\n") {
+		builder.WriteString(prefix + line + "\n")
+	}
+
+	syncWriter{writer: l.fullLogWriter}.Write([]byte(builder.String()))
+}
@@ -0,0 +1,33 @@
+package logger
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/codecrafters-io/tester-utils/color_policy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFullLog(t *testing.T) {
+	color_policy.Set(color_policy.NeverColorPolicy)
+	defer color_policy.Set(color_policy.DefaultColorPolicy)
+
+	fullLog := bytes.NewBuffer([]byte{})
+	SetDefaultFullLogWriter(fullLog)
+	defer SetDefaultFullLogWriter(nil)
+
+	console := bytes.NewBuffer([]byte{})
+	l := GetLoggerWithWriter(console, false, "[test] ")
+	l.Debugf("debug %d", 1)
+	l.WithAdditionalSecondaryPrefix("client", func() {
+		l.Infof("info")
+	})
+	l.FullLogOnlyln("truncated")
+
+	quietLogger := GetQuietLoggerWithWriter(console, "")
+	quietLogger.Infof("hidden")
+	quietLogger.Criticalf("critical")
+
+	assert.Equal(t, "[test] [client] info\ncritical\n", console.String())
+	assert.Equal(t, "[test] debug 1\n[test] [client] info\n[test] truncated\ncritical\n", fullLog.String())
+}
@@ -0,0 +1,105 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GroupStyle controls how log groups (see Logger.WithGroup) are rendered in text mode
+type GroupStyle string
+
+const (
+	// PlainGroupStyle logs the group's title as an info line, and leaves the group's lines as-is. This is the default.
+	PlainGroupStyle GroupStyle = ""
+
+	// IndentedGroupStyle logs the group's title as an info line, and indents the group's lines.
+	IndentedGroupStyle GroupStyle = "indented"
+
+	// GitHubActionsGroupStyle wraps groups in ::group:: and ::endgroup:: workflow commands, so that GitHub Actions
+	// folds them. Nested groups aren't supported by GitHub Actions, so only the outermost group is folded (the titles of
+	// nested groups are logged as info lines, like with PlainGroupStyle).
+	GitHubActionsGroupStyle GroupStyle = "github_actions"
+)
+
+// ParseGroupStyle converts a string like "indented" into a GroupStyle
+func ParseGroupStyle(value string) (GroupStyle, error) {
+	switch GroupStyle(value) {
+	case PlainGroupStyle, IndentedGroupStyle, GitHubActionsGroupStyle:
+		return GroupStyle(value), nil
+	default:
+		return "", fmt.Errorf("group style must be one of indented, github_actions (got %q)", value)
+	}
+}
+
+// WithGroup runs fn, marking all lines logged by this logger in the meantime as a group with the given title.
+//
+// Renderers can use this to fold groups (see GroupStyle). In JSON mode, group_started and group_finished events are
+// emitted around the group and log events list the groups they belong to.
+func (l *Logger) WithGroup(title string, fn func()) {
+	l.startGroup(title)
+	defer l.finishGroup()
+	fn()
+}
+
+// GetGroups returns the titles of the groups that are currently open, outermost first
+func (l *Logger) GetGroups() []string {
+	return l.groups
+}
+
+func (l *Logger) startGroup(title string) {
+	title = l.redact(title)
+
+	if l.shouldLog(InfoLevel) {
+		switch {
+		case l.outputFormat == JSONOutputFormat:
+			l.writeEvent(Event{
+				Type:              GroupStartedEventType,
+				Prefix:            l.getPrefixForEvents(),
+				SecondaryPrefixes: l.secondaryPrefixes,
+				Groups:            l.groups,
+				Message:           title,
+			})
+		case l.GroupStyle == GitHubActionsGroupStyle && len(l.groups) == 0:
+			l.writeRaw("::group::" + l.prefix + title)
+		default:
+			l.logLines("info", infoColorize, "%s", title)
+		}
+	}
+
+	l.groups = append(l.groups, title)
+}
+
+func (l *Logger) finishGroup() {
+	if len(l.groups) == 0 {
+		return
+	}
+
+	title := l.groups[len(l.groups)-1]
+	l.groups = l.groups[:len(l.groups)-1]
+
+	if !l.shouldLog(InfoLevel) {
+		return
+	}
+
+	switch {
+	case l.outputFormat == JSONOutputFormat:
+		l.writeEvent(Event{
+			Type:              GroupFinishedEventType,
+			Prefix:            l.getPrefixForEvents(),
+			SecondaryPrefixes: l.secondaryPrefixes,
+			Groups:            l.groups,
+			Message:           title,
+		})
+	case l.GroupStyle == GitHubActionsGroupStyle && len(l.groups) == 0:
+		l.writeRaw("::endgroup::")
+	}
+}
+
+// getGroupIndentation returns the indentation for lines logged inside groups (only for IndentedGroupStyle)
+func (l *Logger) getGroupIndentation() string {
+	if l.GroupStyle != IndentedGroupStyle {
+		return ""
+	}
+
+	return strings.Repeat("  ", len(l.groups))
+}
@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/codecrafters-io/tester-utils/color_policy"
+	"github.com/stretchr/testify/assert"
+)
+
+func logGroups(l *Logger) {
+	l.Infof("before")
+	l.WithGroup("outer", func() {
+		l.Infof("in outer")
+		l.WithGroup("inner", func() {
+			l.Infof("in inner")
+		})
+	})
+	l.Infof("after")
+}
+
+func TestGroupStyles(t *testing.T) {
+	color_policy.Set(color_policy.NeverColorPolicy)
+	defer color_policy.Set(color_policy.DefaultColorPolicy)
+
+	buffer := bytes.NewBuffer([]byte{})
+	l := GetLoggerWithWriter(buffer, false, "[test] ")
+	logGroups(l)
+	assert.Equal(t, "[test] before\n[test] outer\n[test] in outer\n[test] inner\n[test] in inner\n[test] after\n", buffer.String())
+
+	buffer.Reset()
+	l.GroupStyle = IndentedGroupStyle
+	logGroups(l)
+	assert.Equal(t, "[test] before\n[test] outer\n[test]   in outer\n[test]   inner\n[test]     in inner\n[test] after\n", buffer.String())
+
+	buffer.Reset()
+	l.GroupStyle = GitHubActionsGroupStyle
+	logGroups(l)
+	assert.Equal(t, "[test] before\n::group::[test] outer\n[test] in outer\n[test] inner\n[test] in inner\n::endgroup::\n[test] after\n", buffer.String())
+}
+
+func TestGroupsInJSONOutputFormat(t *testing.T) {
+	buffer := bytes.NewBuffer([]byte{})
+	l := GetLoggerWithWriter(buffer, false, "[test] ")
+	l.outputFormat = JSONOutputFormat
+	logGroups(l)
+
+	events := []Event{}
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		event := Event{}
+		assert.NoError(t, json.Unmarshal([]byte(line), &event))
+		events = append(events, event)
+	}
+
+	assert.Equal(t, []Event{
+		{Type: LogEventType, Level: "info", Prefix: "test", Message: "before"},
+		{Type: GroupStartedEventType, Prefix: "test", Message: "outer"},
+		{Type: LogEventType, Level: "info", Prefix: "test", Groups: []string{"outer"}, Message: "in outer"},
+		{Type: GroupStartedEventType, Prefix: "test", Groups: []string{"outer"}, Message: "inner"},
+		{Type: LogEventType, Level: "info", Prefix: "test", Groups: []string{"outer", "inner"}, Message: "in inner"},
+		{Type: GroupFinishedEventType, Prefix: "test", Groups: []string{"outer"}, Message: "inner"},
+		{Type: GroupFinishedEventType, Prefix: "test", Message: "outer"},
+		{Type: LogEventType, Level: "info", Prefix: "test", Message: "after"},
+	}, events)
+}
@@ -0,0 +1,51 @@
+package logger
+
+import "fmt"
+
+// Level is the severity of a log line. Loggers drop lines below their minimum level (see Logger.MinLevel).
+type Level int
+
+const (
+	// DebugLevel is for logs that are only useful while debugging. Emitted only in debug mode by default.
+	DebugLevel Level = iota + 1
+
+	// InfoLevel is for regular progress logs (Infof, Successf)
+	InfoLevel
+
+	// WarnLevel is for advisories that don't fail a test, like "your program passed, but ..."
+	WarnLevel
+
+	// ErrorLevel is for test failures
+	ErrorLevel
+
+	// CriticalLevel is for logs that must always be shown, even by quiet loggers (see GetQuietLogger)
+	CriticalLevel
+)
+
+func (l Level) String() string {
+	switch l {
+	case DebugLevel:
+		return "debug"
+	case InfoLevel:
+		return "info"
+	case WarnLevel:
+		return "warn"
+	case ErrorLevel:
+		return "error"
+	case CriticalLevel:
+		return "critical"
+	default:
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+}
+
+// ParseLevel converts a string like "warn" into a Level
+func ParseLevel(value string) (Level, error) {
+	for level := DebugLevel; level <= CriticalLevel; level++ {
+		if level.String() == value {
+			return level, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown log level %q, expected one of debug, info, warn, error, critical", value)
+}
@@ -1,6 +1,8 @@
 package logger
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
@@ -8,23 +10,30 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/codecrafters-io/tester-utils/color_policy"
 	"github.com/fatih/color"
 )
 
-func colorize(colorToUse color.Attribute, fstring string, args ...any) []string {
-	var msg string
-
+func formatMessage(fstring string, args ...any) string {
 	if len(args) == 0 {
-		msg = fstring // Treat as plain string if no args
-	} else {
-		msg = fmt.Sprintf(fstring, args...) // Format if args are present
+		return fstring // Treat as plain string if no args
 	}
 
+	return fmt.Sprintf(fstring, args...) // Format if args are present
+}
+
+func colorize(colorToUse color.Attribute, fstring string, args ...any) []string {
+	msg := formatMessage(fstring, args...)
+
 	lines := strings.Split(msg, "\n")
 	colorizedLines := make([]string, len(lines))
 
+	// Loggers only call this if colors are enabled, so we don't need to consult color.NoColor
+	c := color.New(colorToUse)
+	c.EnableColor()
+
 	for i, line := range lines {
-		colorizedLines[i] = color.New(colorToUse).SprintFunc()(line)
+		colorizedLines[i] = c.SprintFunc()(line)
 	}
 
 	return colorizedLines
@@ -42,6 +51,10 @@ func successColorize(fstring string, args ...any) []string {
 	return colorize(color.FgHiGreen, fstring, args...)
 }
 
+func warnColorize(fstring string, args ...any) []string {
+	return colorize(color.FgHiYellow, fstring, args...)
+}
+
 func errorColorize(fstring string, args ...any) []string {
 	return colorize(color.FgHiRed, fstring, args...)
 }
@@ -57,6 +70,113 @@ func plainColorize(fstring string, args ...any) []string {
 // globalLogMutex serializes all logging operations for this package
 var globalLogMutex sync.Mutex
 
+// defaultWriter is used by loggers that aren't given a writer explicitly. If nil, os.Stdout is used.
+var defaultWriter io.Writer
+
+// defaultOutputFormat is used by loggers that aren't given a format explicitly
+var defaultOutputFormat = TextOutputFormat
+
+// defaultTimestampMode is used by new loggers
+var defaultTimestampMode = NoTimestamps
+
+// defaultRedactor is used by new loggers, nil disables redaction
+var defaultRedactor *Redactor
+
+// SetDefaultRedactor sets the Redactor used by loggers created after this call. Pass nil to disable redaction.
+func SetDefaultRedactor(redactor *Redactor) {
+	globalLogMutex.Lock()
+	defer globalLogMutex.Unlock()
+
+	defaultRedactor = redactor
+}
+
+// getDefaultRedactor returns the Redactor that new loggers should use
+func getDefaultRedactor() *Redactor {
+	globalLogMutex.Lock()
+	defer globalLogMutex.Unlock()
+
+	return defaultRedactor
+}
+
+// SetRedactor replaces the logger's Redactor. Pass nil to disable redaction.
+func (l *Logger) SetRedactor(redactor *Redactor) {
+	l.redactor = redactor
+}
+
+// defaultGroupStyle is used by new loggers
+var defaultGroupStyle = PlainGroupStyle
+
+// SetDefaultGroupStyle sets the group style used by loggers created after this call.
+func SetDefaultGroupStyle(groupStyle GroupStyle) {
+	globalLogMutex.Lock()
+	defer globalLogMutex.Unlock()
+
+	defaultGroupStyle = groupStyle
+}
+
+// getDefaultGroupStyle returns the group style that new loggers should use
+func getDefaultGroupStyle() GroupStyle {
+	globalLogMutex.Lock()
+	defer globalLogMutex.Unlock()
+
+	return defaultGroupStyle
+}
+
+// SetDefaultTimestampMode sets the timestamp mode used by loggers created after this call.
+func SetDefaultTimestampMode(timestampMode TimestampMode) {
+	globalLogMutex.Lock()
+	defer globalLogMutex.Unlock()
+
+	defaultTimestampMode = timestampMode
+}
+
+// getDefaultTimestampMode returns the timestamp mode that new loggers should use
+func getDefaultTimestampMode() TimestampMode {
+	globalLogMutex.Lock()
+	defer globalLogMutex.Unlock()
+
+	return defaultTimestampMode
+}
+
+// SetDefaultOutputFormat sets the output format used by loggers created after this call.
+func SetDefaultOutputFormat(outputFormat OutputFormat) {
+	globalLogMutex.Lock()
+	defer globalLogMutex.Unlock()
+
+	defaultOutputFormat = outputFormat
+}
+
+// getDefaultOutputFormat returns the output format that new loggers should use
+func getDefaultOutputFormat() OutputFormat {
+	globalLogMutex.Lock()
+	defer globalLogMutex.Unlock()
+
+	return defaultOutputFormat
+}
+
+// SetDefaultWriter sets the writer used by GetLogger and GetQuietLogger. Pass nil to go back to using os.Stdout.
+//
+// Loggers that were already created aren't affected.
+func SetDefaultWriter(writer io.Writer) {
+	globalLogMutex.Lock()
+	defer globalLogMutex.Unlock()
+
+	defaultWriter = writer
+}
+
+// getDefaultWriter returns the writer that new loggers should use by default
+func getDefaultWriter() io.Writer {
+	globalLogMutex.Lock()
+	defer globalLogMutex.Unlock()
+
+	// os.Stdout is resolved lazily since tests swap it out (see stdio_mocker)
+	if defaultWriter == nil {
+		return os.Stdout
+	}
+
+	return defaultWriter
+}
+
 // Serializes logging using the package-level mutex
 type syncWriter struct {
 	writer io.Writer
@@ -74,6 +194,7 @@ func (s syncWriter) Write(p []byte) (n int, err error) {
 //   - Adds colors to the output
 //   - Debug mode (all logs, debug and above)
 //   - Quiet mode (only critical logs)
+//   - A minimum level (see Level), which overrides debug & quiet mode
 //   - Serialized writes for all loggers in this package
 type Logger struct {
 	// IsDebug is used to determine whether to emit debug logs.
@@ -82,24 +203,72 @@ type Logger struct {
 	// IsQuiet is used to determine whether to emit non-critical logs.
 	IsQuiet bool
 
+	// MinLevel is the minimum level of logs to emit. If not set, it is derived from IsQuiet & IsDebug.
+	//
+	// Plain logs aren't affected by this.
+	MinLevel Level
+
+	// TimestampMode controls whether lines are prefixed with elapsed time (see ResetTimestampClock)
+	TimestampMode TimestampMode
+
+	// GroupStyle controls how log groups are rendered in text mode (see WithGroup)
+	GroupStyle GroupStyle
+
 	// prefix is the prefix to be used for all logs.
 	prefix string
 
 	// secondaryPrefixes is a slice of prefixes that are printed after Logger.prefix
 	secondaryPrefixes []string
 
+	// groups is the stack of open log groups (see WithGroup)
+	groups []string
+
+	// writer is where logs are written to (wrapped in a syncWriter)
+	writer io.Writer
+
+	// outputFormat controls whether logs are rendered as colored text or as JSON events
+	outputFormat OutputFormat
+
+	// shouldColorize is decided by the color policy (see color_policy) when the logger is created
+	shouldColorize bool
+
+	// redactor rewrites sensitive substrings before lines are written, nil if redaction is disabled
+	redactor *Redactor
+
+	// fullLogWriter receives every line regardless of level (see SetDefaultFullLogWriter), nil if disabled
+	fullLogWriter io.Writer
+
+	// capture records emitted lines for capturing loggers (see GetCapturingLogger), nil otherwise
+	capture *lineCapture
+
+	// ctx silences the logger once it is done (see WithContext), nil if not set
+	ctx context.Context
+
 	logger log.Logger
 }
 
-// GetLogger Returns a logger.
+// GetLogger Returns a logger that writes to the default writer (see SetDefaultWriter).
 func GetLogger(isDebug bool, prefix string) *Logger {
-	color.NoColor = false
-	coloredPrefix := yellowColorize("%s", prefix)[0]
-	return &Logger{
-		logger:  *log.New(syncWriter{writer: os.Stdout}, coloredPrefix, 0),
-		IsDebug: isDebug,
-		prefix:  prefix,
+	return GetLoggerWithWriter(getDefaultWriter(), isDebug, prefix)
+}
+
+// GetLoggerWithWriter Returns a logger that writes to the given writer.
+func GetLoggerWithWriter(writer io.Writer, isDebug bool, prefix string) *Logger {
+	l := &Logger{
+		logger:         *log.New(syncWriter{writer: writer}, "", 0),
+		IsDebug:        isDebug,
+		prefix:         prefix,
+		writer:         writer,
+		outputFormat:   getDefaultOutputFormat(),
+		shouldColorize: color_policy.ShouldColorize(writer),
+		TimestampMode:  getDefaultTimestampMode(),
+		GroupStyle:     getDefaultGroupStyle(),
+		redactor:       getDefaultRedactor(),
+		fullLogWriter:  getDefaultFullLogWriter(),
 	}
+	l.updateLoggerPrefix()
+
+	return l
 }
 
 // Clone clones a given logger
@@ -107,15 +276,28 @@ func (l *Logger) Clone() *Logger {
 	secondaryPrefixesCopy := make([]string, len(l.secondaryPrefixes))
 	copy(secondaryPrefixesCopy, l.secondaryPrefixes)
 
-	newSyncWriter := syncWriter{writer: os.Stdout}
-	newColoredPrefix := yellowColorize("%s", l.prefix)[0]
+	groupsCopy := make([]string, len(l.groups))
+	copy(groupsCopy, l.groups)
+
+	newSyncWriter := syncWriter{writer: l.writer}
 
 	cloned := &Logger{
-		logger:            *log.New(newSyncWriter, newColoredPrefix, 0),
+		logger:            *log.New(newSyncWriter, "", 0),
 		IsDebug:           l.IsDebug,
 		IsQuiet:           l.IsQuiet,
+		MinLevel:          l.MinLevel,
 		prefix:            l.prefix,
 		secondaryPrefixes: secondaryPrefixesCopy,
+		writer:            l.writer,
+		outputFormat:      l.outputFormat,
+		shouldColorize:    l.shouldColorize,
+		capture:           l.capture,
+		TimestampMode:     l.TimestampMode,
+		GroupStyle:        l.GroupStyle,
+		redactor:          l.redactor,
+		fullLogWriter:     l.fullLogWriter,
+		groups:            groupsCopy,
+		ctx:               l.ctx,
 	}
 	cloned.updateLoggerPrefix()
 
@@ -155,15 +337,17 @@ func (l *Logger) ResetSecondaryPrefixes() {
 
 // updateLoggerPrefix updates the logger's prefix based on all secondary prefixes
 func (l *Logger) updateLoggerPrefix() {
-	if len(l.secondaryPrefixes) == 0 {
-		l.logger.SetPrefix(yellowColorize("%s", l.prefix)[0])
-	} else {
-		fullPrefix := l.prefix
-		for _, secondaryPrefix := range l.secondaryPrefixes {
-			fullPrefix += fmt.Sprintf("[%s] ", secondaryPrefix)
-		}
-		l.logger.SetPrefix(yellowColorize("%s", fullPrefix)[0])
+	l.logger.SetPrefix(l.colorizeLines(yellowColorize, "%s", l.getPlainPrefix())[0])
+}
+
+// getPlainPrefix returns the primary prefix followed by all secondary prefixes, without colors
+func (l *Logger) getPlainPrefix() string {
+	fullPrefix := l.prefix
+	for _, secondaryPrefix := range l.secondaryPrefixes {
+		fullPrefix += fmt.Sprintf("[%s] ", secondaryPrefix)
 	}
+
+	return fullPrefix
 }
 
 // PushSecondaryPrefix pushes a new secondary prefix to secondaryPrefixes
@@ -191,129 +375,322 @@ func (l *Logger) WithAdditionalSecondaryPrefix(prefix string, fn func()) {
 	fn()
 }
 
+// WithContext returns a clone of the logger that drops all logs once ctx is done. Clones made from it share ctx.
+//
+// Useful for silencing code that might keep running after it was abandoned, like a test function that timed out.
+func (l *Logger) WithContext(ctx context.Context) *Logger {
+	cloned := l.Clone()
+	cloned.ctx = ctx
+
+	return cloned
+}
+
+// isSilenced returns true if the logger's context is done (see WithContext)
+func (l *Logger) isSilenced() bool {
+	return l.ctx != nil && l.ctx.Err() != nil
+}
+
 // GetQuietLogger Returns a logger that only emits critical logs. Useful for anti-cheat stages.
 func GetQuietLogger(prefix string) *Logger {
-	color.NoColor = false
-	coloredPrefix := yellowColorize("%s", prefix)[0]
-	return &Logger{
-		logger:  *log.New(syncWriter{writer: os.Stdout}, coloredPrefix, 0),
-		IsDebug: false,
-		IsQuiet: true,
-		prefix:  prefix,
+	return GetQuietLoggerWithWriter(getDefaultWriter(), prefix)
+}
+
+// GetQuietLoggerWithWriter Returns a quiet logger (see GetQuietLogger) that writes to the given writer.
+func GetQuietLoggerWithWriter(writer io.Writer, prefix string) *Logger {
+	l := &Logger{
+		logger:         *log.New(syncWriter{writer: writer}, "", 0),
+		IsDebug:        false,
+		IsQuiet:        true,
+		prefix:         prefix,
+		writer:         writer,
+		outputFormat:   getDefaultOutputFormat(),
+		shouldColorize: color_policy.ShouldColorize(writer),
+		TimestampMode:  getDefaultTimestampMode(),
+		GroupStyle:     getDefaultGroupStyle(),
+		redactor:       getDefaultRedactor(),
+		fullLogWriter:  getDefaultFullLogWriter(),
 	}
+	l.updateLoggerPrefix()
+
+	return l
 }
 
 func (l *Logger) Successf(fstring string, args ...any) {
-	if l.IsQuiet {
+	if !l.shouldLog(InfoLevel) {
+		l.writeSuppressedToFullLog(fstring, args...)
 		return
 	}
 
-	for _, line := range successColorize(fstring, args...) {
-		l.logger.Println(line)
-	}
+	l.logLines("success", successColorize, fstring, args...)
 }
 
 func (l *Logger) Successln(msg string) {
-	if l.IsQuiet {
+	if !l.shouldLog(InfoLevel) {
+		l.writeSuppressedToFullLog("%s", msg)
 		return
 	}
-	for _, line := range successColorize("%s", msg) {
-		l.logger.Println(line)
-	}
+	l.logLines("success", successColorize, "%s", msg)
 }
 
 func (l *Logger) Infof(fstring string, args ...any) {
-	if l.IsQuiet {
+	if !l.shouldLog(InfoLevel) {
+		l.writeSuppressedToFullLog(fstring, args...)
 		return
 	}
 
-	for _, line := range infoColorize(fstring, args...) {
-		l.logger.Println(line)
-	}
+	l.logLines("info", infoColorize, fstring, args...)
 }
 
 func (l *Logger) Infoln(msg string) {
-	if l.IsQuiet {
+	if !l.shouldLog(InfoLevel) {
+		l.writeSuppressedToFullLog("%s", msg)
 		return
 	}
 
-	for _, line := range infoColorize("%s", msg) {
-		l.logger.Println(line)
-	}
+	l.logLines("info", infoColorize, "%s", msg)
 }
 
-// Criticalf is to be used only in anti-cheat stages
+// Criticalf logs a message that is always shown, even by quiet loggers (like the ones used in anti-cheat stages).
+//
+// Rendered like Errorf, so helpers shared between anti-cheat & regular stages can use it regardless of the mode.
 func (l *Logger) Criticalf(fstring string, args ...any) {
-	if !l.IsQuiet {
-		panic("Critical is only for quiet loggers")
-	}
-
-	for _, line := range errorColorize(fstring, args...) {
-		l.logger.Println(line)
-	}
+	l.logLines("critical", errorColorize, fstring, args...)
 }
 
-// Criticalln is to be used only in anti-cheat stages
+// Criticalln is like Criticalf, but for a plain message
 func (l *Logger) Criticalln(msg string) {
-	if !l.IsQuiet {
-		panic("Critical is only for quiet loggers")
+	l.logLines("critical", errorColorize, "%s", msg)
+}
+
+func (l *Logger) Warnf(fstring string, args ...any) {
+	if !l.shouldLog(WarnLevel) {
+		l.writeSuppressedToFullLog(fstring, args...)
+		return
 	}
 
-	for _, line := range errorColorize("%s", msg) {
-		l.logger.Println(line)
+	l.logLines("warn", warnColorize, fstring, args...)
+}
+
+func (l *Logger) Warnln(msg string) {
+	if !l.shouldLog(WarnLevel) {
+		l.writeSuppressedToFullLog("%s", msg)
+		return
 	}
+
+	l.logLines("warn", warnColorize, "%s", msg)
 }
 
 func (l *Logger) Errorf(fstring string, args ...any) {
-	if l.IsQuiet {
+	if !l.shouldLog(ErrorLevel) {
+		l.writeSuppressedToFullLog(fstring, args...)
 		return
 	}
 
-	for _, line := range errorColorize(fstring, args...) {
-		l.logger.Println(line)
-	}
+	l.logLines("error", errorColorize, fstring, args...)
 }
 
 func (l *Logger) Errorln(msg string) {
-	if l.IsQuiet {
+	if !l.shouldLog(ErrorLevel) {
+		l.writeSuppressedToFullLog("%s", msg)
 		return
 	}
 
-	for _, line := range errorColorize("%s", msg) {
-		l.logger.Println(line)
-	}
+	l.logLines("error", errorColorize, "%s", msg)
 }
 
 func (l *Logger) Debugf(fstring string, args ...any) {
-	if !l.IsDebug {
+	if !l.shouldLog(DebugLevel) {
+		l.writeSuppressedToFullLog(fstring, args...)
 		return
 	}
 
-	for _, line := range debugColorize(fstring, args...) {
-		l.logger.Println(line)
-	}
+	l.logLines("debug", debugColorize, fstring, args...)
 }
 
 func (l *Logger) Debugln(msg string) {
-	if !l.IsDebug {
+	if !l.shouldLog(DebugLevel) {
+		l.writeSuppressedToFullLog("%s", msg)
 		return
 	}
 
-	for _, line := range debugColorize("%s", msg) {
-		l.logger.Println(line)
-	}
+	l.logLines("debug", debugColorize, "%s", msg)
 }
 
 func (l *Logger) Plainf(fstring string, args ...any) {
 	formattedString := fmt.Sprintf(fstring, args...)
 
-	for _, line := range plainColorize("%s", formattedString) {
-		l.logger.Println(line)
-	}
+	l.logLines("plain", plainColorize, "%s", formattedString)
 }
 
 func (l *Logger) Plainln(msg string) {
-	for _, line := range plainColorize("%s", msg) {
-		l.logger.Println(line)
+	l.logLines("plain", plainColorize, "%s", msg)
+}
+
+// ProgramOutputln logs a line of output from the user's program. Rendered like Plainln in text mode.
+func (l *Logger) ProgramOutputln(msg string) {
+	if l.isSilenced() {
+		return
+	}
+
+	if l.outputFormat == JSONOutputFormat {
+		msg = l.redact(msg)
+		l.captureLine(ProgramOutputEventType, msg)
+		l.writeToFullLog(msg)
+
+		l.writeEvent(Event{
+			Type:              ProgramOutputEventType,
+			Prefix:            l.getPrefixForEvents(),
+			SecondaryPrefixes: l.secondaryPrefixes,
+			Message:           msg,
+		})
+
+		return
 	}
+
+	l.logLines(ProgramOutputEventType, plainColorize, "%s", msg)
+}
+
+// BlankLine writes an empty line without any prefixes. Used to separate stages. Skipped on the console in JSON mode.
+func (l *Logger) BlankLine() {
+	if l.isSilenced() {
+		return
+	}
+
+	if l.fullLogWriter != nil {
+		syncWriter{writer: l.fullLogWriter}.Write([]byte("\n"))
+	}
+
+	if l.outputFormat == JSONOutputFormat {
+		return
+	}
+
+	l.writeRaw("")
+}
+
+// writeRaw writes a line without any prefixes, colors or timestamps
+func (l *Logger) writeRaw(line string) {
+	if l.isSilenced() {
+		return
+	}
+
+	syncWriter{writer: l.writer}.Write([]byte(line + "\n"))
+}
+
+// EmitEvent writes a lifecycle event (like StageStartedEventType) in JSON mode. No-op in text mode and for quiet loggers.
+func (l *Logger) EmitEvent(event Event) {
+	if l.outputFormat != JSONOutputFormat || l.IsQuiet || l.isSilenced() {
+		return
+	}
+
+	event.Message = l.redact(event.Message)
+	event.Error = l.redact(event.Error)
+	l.writeEvent(event)
+}
+
+// GetMinLevel returns the minimum level of logs that'll be emitted
+func (l *Logger) GetMinLevel() Level {
+	if l.MinLevel != 0 {
+		return l.MinLevel
+	}
+
+	if l.IsQuiet {
+		return CriticalLevel
+	}
+
+	if l.IsDebug {
+		return DebugLevel
+	}
+
+	return InfoLevel
+}
+
+// shouldLog returns true if logs at the given level should be emitted
+func (l *Logger) shouldLog(level Level) bool {
+	return level >= l.GetMinLevel()
+}
+
+// logLines writes a (possibly multi-line) message, one line at a time
+func (l *Logger) logLines(level string, colorizeFunc func(string, ...any) []string, fstring string, args ...any) {
+	if l.isSilenced() {
+		return
+	}
+
+	msg := l.redact(formatMessage(fstring, args...))
+
+	for _, line := range strings.Split(msg, "\n") {
+		l.captureLine(level, line)
+	}
+
+	l.writeToFullLog(msg)
+
+	if l.outputFormat == JSONOutputFormat {
+		for _, line := range strings.Split(msg, "\n") {
+			l.writeEvent(Event{
+				Type:              LogEventType,
+				Level:             level,
+				Prefix:            l.getPrefixForEvents(),
+				SecondaryPrefixes: l.secondaryPrefixes,
+				Groups:            l.groups,
+				Message:           line,
+			})
+		}
+
+		return
+	}
+
+	indentation := l.getGroupIndentation()
+
+	for _, line := range l.colorizeLines(colorizeFunc, "%s", msg) {
+		line = indentation + line
+
+		if l.TimestampMode == NoTimestamps {
+			l.logger.Println(line)
+			continue
+		}
+
+		timestamp := l.colorizeLines(yellowColorize, "%s", globalTimestampClock.nextTimestamp(l.TimestampMode))[0]
+		l.logger.Writer().Write([]byte(timestamp + l.logger.Prefix() + line + "\n"))
+	}
+}
+
+// redact applies the logger's Redactor (if any) to a message
+func (l *Logger) redact(msg string) string {
+	if l.redactor == nil {
+		return msg
+	}
+
+	return l.redactor.Redact(msg)
+}
+
+// colorizeLines formats a message and splits it into lines, colorizing them if the color policy allows it
+func (l *Logger) colorizeLines(colorizeFunc func(string, ...any) []string, fstring string, args ...any) []string {
+	if !l.shouldColorize {
+		return strings.Split(formatMessage(fstring, args...), "\n")
+	}
+
+	return colorizeFunc(fstring, args...)
+}
+
+// getPrefixForEvents returns the primary prefix without decorations, "[tester::#1] " becomes "tester::#1"
+func (l *Logger) getPrefixForEvents() string {
+	prefix := strings.TrimSpace(l.prefix)
+
+	if strings.HasPrefix(prefix, "[") && strings.HasSuffix(prefix, "]") {
+		prefix = prefix[1 : len(prefix)-1]
+	}
+
+	return prefix
+}
+
+func (l *Logger) writeEvent(event Event) {
+	if l.isSilenced() {
+		return
+	}
+
+	eventBytes, err := json.Marshal(event)
+	if err != nil {
+		panic(fmt.Sprintf("CodeCrafters Internal Error - failed to encode log event: %s", err))
+	}
+
+	syncWriter{writer: l.writer}.Write(append(eventBytes, '\n'))
 }
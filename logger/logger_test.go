@@ -0,0 +1,143 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-io/tester-utils/color_policy"
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoggerWithWriter(t *testing.T) {
+	buffer := bytes.NewBuffer([]byte{})
+	l := GetLoggerWithWriter(buffer, false, "[test] ")
+
+	l.Plainln("hello")
+	l.Clone().Plainln("from clone")
+
+	assert.Contains(t, buffer.String(), "hello")
+	assert.Contains(t, buffer.String(), "from clone")
+}
+
+func TestDefaultWriter(t *testing.T) {
+	buffer := bytes.NewBuffer([]byte{})
+	SetDefaultWriter(buffer)
+	defer SetDefaultWriter(nil)
+
+	GetLogger(false, "[test] ").Plainln("hello")
+	GetQuietLogger("").Criticalln("critical")
+
+	assert.Contains(t, buffer.String(), "hello")
+	assert.Contains(t, buffer.String(), "critical")
+}
+
+func TestLoggerRespectsColorPolicy(t *testing.T) {
+	buffer := bytes.NewBuffer([]byte{})
+
+	color_policy.Set(color_policy.NeverColorPolicy)
+	defer color_policy.Set(color_policy.DefaultColorPolicy)
+
+	l := GetLoggerWithWriter(buffer, false, "[test] ")
+	l.WithAdditionalSecondaryPrefix("client", func() {
+		l.Infof("hello")
+	})
+
+	assert.Equal(t, "[test] [client] hello\n", buffer.String())
+}
+
+func TestColorDecisionIsPerLogger(t *testing.T) {
+	color_policy.Set(color_policy.AutoColorPolicy)
+	defer color_policy.Set(color_policy.DefaultColorPolicy)
+
+	originalNoColor := color.NoColor
+	defer func() { color.NoColor = originalNoColor }()
+	color.NoColor = false
+
+	// Buffers aren't terminals, so this logger doesn't use colors...
+	GetCapturingLogger(false, "[test] ").Infof("hello")
+
+	// ...but that doesn't affect anyone else using fatih/color
+	assert.False(t, color.NoColor)
+}
+
+func TestLevels(t *testing.T) {
+	color_policy.Set(color_policy.NeverColorPolicy)
+	defer color_policy.Set(color_policy.DefaultColorPolicy)
+
+	buffer := bytes.NewBuffer([]byte{})
+	l := GetLoggerWithWriter(buffer, false, "")
+	l.Debugf("debug")
+	l.Infof("info")
+	l.Warnf("warn")
+	l.Errorf("error")
+	assert.Equal(t, "info\nwarn\nerror\n", buffer.String())
+
+	buffer.Reset()
+	l.MinLevel = WarnLevel
+	l.Infof("info")
+	l.Warnf("warn")
+	l.Plainf("plain")
+	assert.Equal(t, "warn\nplain\n", buffer.String())
+
+	buffer.Reset()
+	quietLogger := GetQuietLoggerWithWriter(buffer, "")
+	quietLogger.Warnf("warn")
+	quietLogger.Criticalf("critical")
+	assert.Equal(t, "critical\n", buffer.String())
+	assert.Equal(t, CriticalLevel, quietLogger.GetMinLevel())
+}
+
+func TestCriticalOutsideQuietMode(t *testing.T) {
+	color_policy.Set(color_policy.NeverColorPolicy)
+	defer color_policy.Set(color_policy.DefaultColorPolicy)
+
+	buffer := bytes.NewBuffer([]byte{})
+	l := GetLoggerWithWriter(buffer, false, "[test] ")
+	l.MinLevel = ErrorLevel
+
+	assert.NotPanics(t, func() {
+		l.Criticalf("critical %d", 1)
+		l.Criticalln("critical 2")
+	})
+	assert.Equal(t, "[test] critical 1\n[test] critical 2\n", buffer.String())
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := ParseLevel("warn")
+	assert.NoError(t, err)
+	assert.Equal(t, WarnLevel, level)
+
+	_, err = ParseLevel("loud")
+	assert.Error(t, err)
+}
+
+func TestTimestamps(t *testing.T) {
+	color_policy.Set(color_policy.NeverColorPolicy)
+	defer color_policy.Set(color_policy.DefaultColorPolicy)
+
+	buffer := bytes.NewBuffer([]byte{})
+	l := GetLoggerWithWriter(buffer, false, "[test] ")
+	l.TimestampMode = ElapsedTimestamps
+
+	ResetTimestampClock()
+	l.Infof("first")
+	time.Sleep(20 * time.Millisecond)
+	l.Infof("second")
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	assert.Regexp(t, `^\[0\.00\ds\] \[test\] first$`, lines[0])
+	assert.Regexp(t, `^\[0\.0[2-9]\ds\] \[test\] second$`, lines[1])
+
+	buffer.Reset()
+	l.TimestampMode = DeltaTimestamps
+	time.Sleep(20 * time.Millisecond)
+	l.Infof("third")
+	l.Infof("fourth")
+
+	lines = strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	assert.Regexp(t, `^\[\+0\.0[2-9]\ds\] \[test\] third$`, lines[0])
+	assert.Regexp(t, `^\[\+0\.00\ds\] \[test\] fourth$`, lines[1])
+}
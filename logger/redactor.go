@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Redactor rewrites sensitive or noisy substrings (like tokens or temp paths) in log lines before they're written.
+//
+// Rules should be added before the Redactor is passed to loggers, Redactors aren't safe for concurrent modification.
+type Redactor struct {
+	rules []redactionRule
+}
+
+type redactionRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// NewRedactor returns a Redactor with no rules
+func NewRedactor() *Redactor {
+	return &Redactor{}
+}
+
+// AddPattern replaces matches of pattern with replacement (which can use $1-style references, see regexp.Expand)
+func (r *Redactor) AddPattern(pattern *regexp.Regexp, replacement string) *Redactor {
+	r.rules = append(r.rules, redactionRule{pattern: pattern, replacement: replacement})
+	return r
+}
+
+// AddLiteral replaces all occurrences of value with replacement. Empty values are ignored.
+func (r *Redactor) AddLiteral(value string, replacement string) *Redactor {
+	if value == "" {
+		return r
+	}
+
+	return r.AddPattern(regexp.MustCompile(regexp.QuoteMeta(value)), strings.ReplaceAll(replacement, "$", "$$"))
+}
+
+// AddSecretsFromEnvironment redacts the values of all CODECRAFTERS_SECRET* environment variables
+func (r *Redactor) AddSecretsFromEnvironment() *Redactor {
+	for _, envVar := range os.Environ() {
+		if !strings.HasPrefix(envVar, "CODECRAFTERS_SECRET") {
+			continue
+		}
+
+		name, value, _ := strings.Cut(envVar, "=")
+		r.AddLiteral(value, "<redacted:"+name+">")
+	}
+
+	return r
+}
+
+// AddTempPaths replaces absolute paths inside the system's temp directory (like /tmp/abc123/file.txt) with "<temp path>"
+func (r *Redactor) AddTempPaths() *Redactor {
+	tempDir := strings.TrimSuffix(os.TempDir(), string(os.PathSeparator))
+	return r.AddPattern(regexp.MustCompile(regexp.QuoteMeta(tempDir)+`(?:/[^\s"'/]+)+/?`), "<temp path>")
+}
+
+// Clone returns a copy of the Redactor that rules can be added to without affecting the original
+func (r *Redactor) Clone() *Redactor {
+	rulesCopy := make([]redactionRule, len(r.rules))
+	copy(rulesCopy, r.rules)
+
+	return &Redactor{rules: rulesCopy}
+}
+
+// Redact applies all rules to s, in the order they were added
+func (r *Redactor) Redact(s string) string {
+	for _, rule := range r.rules {
+		s = rule.pattern.ReplaceAllString(s, rule.replacement)
+	}
+
+	return s
+}
@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/codecrafters-io/tester-utils/color_policy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactor(t *testing.T) {
+	redactor := NewRedactor().
+		AddLiteral("s3cr3t.token", "<token>").
+		AddPattern(regexp.MustCompile(`session=(\w+)`), "session=<id:$1>")
+
+	assert.Equal(t, "token: <token>, session=<id:abc>", redactor.Redact("token: s3cr3t.token, session=abc"))
+	assert.Equal(t, "s3cr3tXtoken", redactor.Redact("s3cr3tXtoken"))
+
+	cloned := redactor.Clone().AddLiteral("extra", "$1")
+	assert.Equal(t, "$1", cloned.Redact("extra"))
+	assert.Equal(t, "extra", redactor.Redact("extra"))
+}
+
+func TestRedactorSecretsFromEnvironment(t *testing.T) {
+	os.Setenv("CODECRAFTERS_SECRET_API_KEY", "secret-key-123")
+	defer os.Unsetenv("CODECRAFTERS_SECRET_API_KEY")
+
+	redactor := NewRedactor().AddSecretsFromEnvironment()
+	assert.Equal(t, "key: <redacted:CODECRAFTERS_SECRET_API_KEY>", redactor.Redact("key: secret-key-123"))
+}
+
+func TestRedactorTempPaths(t *testing.T) {
+	tempPath := filepath.Join(os.TempDir(), "abc123", "file.txt")
+
+	redactor := NewRedactor().AddTempPaths()
+	assert.Equal(t, "wrote to <temp path>", redactor.Redact("wrote to "+tempPath))
+}
+
+func TestLoggerRedaction(t *testing.T) {
+	color_policy.Set(color_policy.NeverColorPolicy)
+	defer color_policy.Set(color_policy.DefaultColorPolicy)
+
+	SetDefaultRedactor(NewRedactor().AddLiteral("hunter2", "<password>"))
+	defer SetDefaultRedactor(nil)
+
+	buffer := bytes.NewBuffer([]byte{})
+	l := GetLoggerWithWriter(buffer, false, "")
+	l.Infof("password is %s", "hunter2")
+	l.Clone().Infof("hunter2 again")
+
+	assert.Equal(t, "password is <password>\n<password> again\n", buffer.String())
+}
@@ -0,0 +1,82 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// TimestampMode controls whether log lines are prefixed with elapsed time
+type TimestampMode string
+
+const (
+	// NoTimestamps doesn't add any timestamps. This is the default.
+	NoTimestamps TimestampMode = ""
+
+	// ElapsedTimestamps prefixes lines with the time elapsed since the current stage started. Example: "[1.204s] "
+	ElapsedTimestamps TimestampMode = "elapsed"
+
+	// DeltaTimestamps prefixes lines with the time elapsed since the previous line. Example: "[+0.012s] "
+	DeltaTimestamps TimestampMode = "delta"
+)
+
+// ParseTimestampMode converts a string like "elapsed" into a TimestampMode
+func ParseTimestampMode(value string) (TimestampMode, error) {
+	switch TimestampMode(value) {
+	case NoTimestamps, ElapsedTimestamps, DeltaTimestamps:
+		return TimestampMode(value), nil
+	default:
+		return "", fmt.Errorf("timestamp mode must be one of elapsed, delta (got %q)", value)
+	}
+}
+
+// timestampClock tracks the reference times used for timestamps. All loggers share a single clock, so that the
+// tester's logs and the program's output (which use different loggers) line up.
+type timestampClock struct {
+	mutex            sync.Mutex
+	startTime        time.Time
+	previousLineTime time.Time
+}
+
+var globalTimestampClock = newTimestampClock()
+
+func newTimestampClock() *timestampClock {
+	now := time.Now()
+
+	return &timestampClock{
+		startTime:        now,
+		previousLineTime: now,
+	}
+}
+
+// ResetTimestampClock marks the start of a stage, ElapsedTimestamps are relative to the last call.
+func ResetTimestampClock() {
+	globalTimestampClock.reset()
+}
+
+func (c *timestampClock) reset() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.startTime = time.Now()
+	c.previousLineTime = c.startTime
+}
+
+// nextTimestamp returns the timestamp prefix for a line that is about to be emitted
+func (c *timestampClock) nextTimestamp(mode TimestampMode) string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+	previousLineTime := c.previousLineTime
+	c.previousLineTime = now
+
+	switch mode {
+	case ElapsedTimestamps:
+		return fmt.Sprintf("[%.3fs] ", now.Sub(c.startTime).Seconds())
+	case DeltaTimestamps:
+		return fmt.Sprintf("[+%.3fs] ", now.Sub(previousLineTime).Seconds())
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,47 @@
+package test_case_harness
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SkipOutcome can be returned from a TestFunc (see Skip) to mark the test case as skipped instead of passed or failed.
+// Skipped test cases don't fail the run.
+type SkipOutcome struct {
+	// Reason is shown to the user. Example: "Not applicable to Haskell"
+	Reason string
+}
+
+func (o *SkipOutcome) Error() string {
+	return fmt.Sprintf("skipped: %s", o.Reason)
+}
+
+// Skip returns a SkipOutcome. Use it like this:
+//
+//	if language == "haskell" {
+//	    return test_case_harness.Skip("Not applicable to Haskell")
+//	}
+func Skip(reason string) error {
+	return &SkipOutcome{Reason: reason}
+}
+
+// PassWithWarningsOutcome can be returned from a TestFunc (see PassWithWarnings) to mark the test case as passed, while
+// making sure the user sees the warnings.
+type PassWithWarningsOutcome struct {
+	Warnings []string
+}
+
+func (o *PassWithWarningsOutcome) Error() string {
+	return fmt.Sprintf("passed with warnings: %s", strings.Join(o.Warnings, "; "))
+}
+
+// PassWithWarnings returns a PassWithWarningsOutcome, or nil if there are no warnings. Use it like this:
+//
+//	return test_case_harness.PassWithWarnings("Response was slow (2.1s), this might fail in later stages")
+func PassWithWarnings(warnings ...string) error {
+	if len(warnings) == 0 {
+		return nil
+	}
+
+	return &PassWithWarningsOutcome{Warnings: warnings}
+}
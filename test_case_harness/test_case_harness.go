@@ -1,6 +1,11 @@
 package test_case_harness
 
 import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
 	"github.com/codecrafters-io/tester-utils/executable"
 	"github.com/codecrafters-io/tester-utils/logger"
 )
@@ -28,20 +33,135 @@ type TestCaseHarness struct {
 	// Executable is the program to be tested.
 	Executable *executable.Executable
 
-	// teardownFuncs are run once the error has been reported to the user
+	// teardownFuncs are run once the error has been reported to the user, in reverse order of registration
 	teardownFuncs []func()
+
+	// teardownFuncTimeout is how long each teardown func can run for. Defaults to defaultTeardownFuncTimeout.
+	teardownFuncTimeout time.Duration
+
+	// reportingLogger is used to report teardown failures. Unlike Logger, it isn't silenced by Cancel.
+	reportingLogger *logger.Logger
+
+	// ctx is cancelled when the test case times out (see Cancel)
+	ctx        context.Context
+	cancelFunc context.CancelFunc
+
+	// executables are terminated on Cancel. Guarded by executablesMutex since Cancel runs on the test runner's goroutine.
+	executables      []*executable.Executable
+	executablesMutex sync.Mutex
+}
+
+// NewTestCaseHarness returns a TestCaseHarness whose Logger is silenced and whose executables are terminated (and stop
+// logging their output) when Cancel is called.
+func NewTestCaseHarness(l *logger.Logger, e *executable.Executable) *TestCaseHarness {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+
+	// Executables can't be started (or log output) once the harness is cancelled, even by a test function that is still
+	// running
+	e.Context = ctx
+
+	return &TestCaseHarness{
+		Logger:      l.WithContext(ctx),
+		Executable:  e,
+		ctx:         ctx,
+		cancelFunc:  cancelFunc,
+		executables: []*executable.Executable{e},
+
+		reportingLogger: l,
+	}
+}
+
+// Context is cancelled when the test case times out. Test functions that block (on network reads, for example) should
+// return once it is done.
+func (s *TestCaseHarness) Context() context.Context {
+	if s.ctx == nil {
+		return context.Background()
+	}
+
+	return s.ctx
+}
+
+// Cancel is called by the test runner when the test case times out. It cancels Context, silences Logger (and its
+// clones) and terminates all executables created through the harness. Output they flush afterwards isn't logged, and
+// starting them fails.
+func (s *TestCaseHarness) Cancel() {
+	if s.cancelFunc != nil {
+		s.cancelFunc()
+	}
+
+	s.executablesMutex.Lock()
+	defer s.executablesMutex.Unlock()
+
+	for _, e := range s.executables {
+		e.Terminate()
+	}
 }
 
+// defaultTeardownFuncTimeout is how long a teardown func can run for before it's abandoned
+const defaultTeardownFuncTimeout = 5 * time.Second
+
+// RegisterTeardownFunc registers a function to be run once the test case is done. Like defer, teardown funcs are run
+// in reverse order, so a server registered before its clients is torn down after them.
 func (s *TestCaseHarness) RegisterTeardownFunc(teardownFunc func()) {
 	s.teardownFuncs = append(s.teardownFuncs, teardownFunc)
 }
 
+// RunTeardownFuncs runs all registered teardown funcs, most recently registered first.
+//
+// Each teardown func is given a bounded amount of time to run, and panics are recovered so that the remaining ones
+// still run. Failures are logged in debug mode.
 func (s *TestCaseHarness) RunTeardownFuncs() {
-	for _, teardownFunc := range s.teardownFuncs {
+	for i := len(s.teardownFuncs) - 1; i >= 0; i-- {
+		if err := s.runTeardownFunc(s.teardownFuncs[i]); err != nil {
+			s.getReportingLogger().Debugf("Teardown func #%d failed: %s", i+1, err)
+		}
+	}
+}
+
+// runTeardownFunc runs a teardown func, returning an error if it panics or doesn't return in time
+func (s *TestCaseHarness) runTeardownFunc(teardownFunc func()) error {
+	doneChannel := make(chan error, 1)
+
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				doneChannel <- fmt.Errorf("panicked: %v", recovered)
+			}
+		}()
+
 		teardownFunc()
+		doneChannel <- nil
+	}()
+
+	timeout := s.teardownFuncTimeout
+	if timeout == 0 {
+		timeout = defaultTeardownFuncTimeout
+	}
+
+	select {
+	case err := <-doneChannel:
+		return err
+	case <-time.After(timeout):
+		return fmt.Errorf("timed out after %s", timeout)
 	}
 }
 
+// getReportingLogger returns the logger used to report teardown failures
+func (s *TestCaseHarness) getReportingLogger() *logger.Logger {
+	if s.reportingLogger == nil {
+		return s.Logger
+	}
+
+	return s.reportingLogger
+}
+
 func (s *TestCaseHarness) NewExecutable() *executable.Executable {
-	return s.Executable.Clone()
+	e := s.Executable.Clone()
+
+	s.executablesMutex.Lock()
+	defer s.executablesMutex.Unlock()
+
+	s.executables = append(s.executables, e)
+
+	return e
 }
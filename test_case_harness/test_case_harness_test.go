@@ -0,0 +1,97 @@
+package test_case_harness
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-io/tester-utils/executable"
+	"github.com/codecrafters-io/tester-utils/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunTeardownFuncs(t *testing.T) {
+	l := logger.GetCapturingLogger(true, "")
+	harness := NewTestCaseHarness(l, executable.NewExecutable("sleep"))
+	harness.teardownFuncTimeout = 50 * time.Millisecond
+
+	order := []int{}
+
+	harness.RegisterTeardownFunc(func() { order = append(order, 1) })
+	harness.RegisterTeardownFunc(func() { panic("oops") })
+	harness.RegisterTeardownFunc(func() { time.Sleep(time.Second) })
+	harness.RegisterTeardownFunc(func() { order = append(order, 4) })
+
+	harness.RunTeardownFuncs()
+
+	assert.Equal(t, []int{4, 1}, order)
+	assert.Equal(t, []string{
+		"Teardown func #3 failed: timed out after 50ms",
+		"Teardown func #2 failed: panicked: oops",
+	}, l.GetCapturedMessages())
+}
+
+func TestTeardownFailuresAreReportedAfterCancel(t *testing.T) {
+	l := logger.GetCapturingLogger(true, "")
+	harness := NewTestCaseHarness(l, executable.NewExecutable("sleep"))
+
+	harness.RegisterTeardownFunc(func() { panic("oops") })
+	harness.Cancel()
+	harness.Logger.Infof("Ghost log")
+	harness.RunTeardownFuncs()
+
+	assert.Equal(t, []string{"Teardown func #1 failed: panicked: oops"}, l.GetCapturedMessages())
+}
+
+func TestExecutablesCantBeStartedAfterCancel(t *testing.T) {
+	harness := NewTestCaseHarness(logger.GetCapturingLogger(false, ""), executable.NewExecutable("sleep"))
+
+	running := harness.NewExecutable()
+	assert.NoError(t, running.Start("60"))
+
+	harness.Cancel()
+
+	result, _ := running.Wait()
+	assert.Equal(t, 137, result.ExitCode) // SIGKILL
+
+	// A timed-out test function might still try to start executables
+	err := harness.NewExecutable().Start("60")
+	assert.ErrorIs(t, err, context.Canceled)
+
+	err = harness.Executable.Start("60")
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestOutputIsDroppedAfterCancel(t *testing.T) {
+	var mutex sync.Mutex
+	loggedLines := []string{}
+
+	e := executable.NewVerboseExecutable("bash", func(line string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		loggedLines = append(loggedLines, line)
+	})
+	harness := NewTestCaseHarness(logger.GetCapturingLogger(false, ""), e)
+
+	running := harness.NewExecutable()
+	assert.NoError(t, running.Start("-c", "echo complete; printf partial; sleep 60"))
+
+	assert.Eventually(t, func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		return len(loggedLines) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	harness.Cancel()
+
+	// Wait flushes the partial line, which must not be logged since the test case is over
+	result, _ := running.Wait()
+	assert.Equal(t, "complete\npartial", string(result.Stdout))
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	assert.Equal(t, []string{"complete"}, loggedLines)
+}
@@ -0,0 +1,128 @@
+package test_report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+
+	"github.com/codecrafters-io/tester-utils/test_runner"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string          `xml:"name,attr"`
+	ClassName string          `xml:"classname,attr"`
+	Time      string          `xml:"time,attr"`
+	Failure   *junitFailure   `xml:"failure,omitempty"`
+	Skipped   *junitSkipped   `xml:"skipped,omitempty"`
+	SystemOut *junitSystemOut `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr,omitempty"`
+}
+
+// junitSystemOut holds log lines, as CDATA so that newlines aren't escaped
+type junitSystemOut struct {
+	Text string `xml:",cdata"`
+}
+
+// WriteJUnitXML writes the report to path in the JUnit XML format understood by most CI systems. Each stage is a test
+// case (named after the stage's title, with the slug as the class name) in the "tester" test suite. If anti-cheat tests
+// ran, their overall status is a single test case in a separate "anti-cheat" test suite.
+func (r Report) WriteJUnitXML(path string) error {
+	suites := junitTestSuites{Suites: []junitTestSuite{}}
+	totalDurationInMilliseconds := int64(0)
+
+	addSuite := func(name string, stages []Stage) {
+		suite, durationInMilliseconds := newJUnitTestSuite(name, stages)
+
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Skipped += suite.Skipped
+		suites.Suites = append(suites.Suites, suite)
+		totalDurationInMilliseconds += durationInMilliseconds
+	}
+
+	addSuite("tester", r.Stages)
+
+	if r.AntiCheatStatus != "" {
+		antiCheatStage := Stage{Slug: "anti-cheat", Title: "Anti-cheat tests", Status: r.AntiCheatStatus}
+		if r.AntiCheatStatus == string(test_runner.FailedStepStatus) {
+			antiCheatStage.FailureMessage = "anti-cheat tests failed"
+		}
+
+		addSuite("anti-cheat", []Stage{antiCheatStage})
+	}
+
+	suites.Time = formatJUnitDuration(totalDurationInMilliseconds)
+
+	reportBytes, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append([]byte(xml.Header), append(reportBytes, '\n')...), 0644)
+}
+
+// newJUnitTestSuite builds a test suite from stages, also returning the suite's total duration
+func newJUnitTestSuite(name string, stages []Stage) (junitTestSuite, int64) {
+	suite := junitTestSuite{Name: name, TestCases: []junitTestCase{}}
+	totalDurationInMilliseconds := int64(0)
+
+	for _, stage := range stages {
+		testCase := junitTestCase{
+			Name:      stage.Title,
+			ClassName: stage.Slug,
+			Time:      formatJUnitDuration(stage.DurationInMilliseconds),
+		}
+
+		if logText := stage.getLogText(); logText != "" {
+			testCase.SystemOut = &junitSystemOut{Text: logText}
+		}
+
+		switch stage.Status {
+		case string(test_runner.FailedStepStatus):
+			testCase.Failure = &junitFailure{Message: stage.FailureMessage, Text: stage.FailureMessage}
+			suite.Failures++
+		case string(test_runner.SkippedStepStatus):
+			testCase.Skipped = &junitSkipped{Message: stage.SkipReason}
+			suite.Skipped++
+		}
+
+		suite.Tests++
+		suite.TestCases = append(suite.TestCases, testCase)
+		totalDurationInMilliseconds += stage.DurationInMilliseconds
+	}
+
+	suite.Time = formatJUnitDuration(totalDurationInMilliseconds)
+
+	return suite, totalDurationInMilliseconds
+}
+
+// formatJUnitDuration formats a duration in seconds, as JUnit expects. Example: "1.204"
+func formatJUnitDuration(durationInMilliseconds int64) string {
+	return fmt.Sprintf("%.3f", float64(durationInMilliseconds)/1000)
+}
@@ -0,0 +1,212 @@
+package test_report
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/codecrafters-io/tester-utils/logger"
+	"github.com/codecrafters-io/tester-utils/test_case_harness"
+	"github.com/codecrafters-io/tester-utils/test_runner"
+)
+
+// Report is a machine-readable summary of a tester run. The JSON encoding of this struct is a stable schema that
+// scripts can depend on, fields will only be added (never renamed or removed).
+type Report struct {
+	// Status is "passed" if all stages (and anti-cheat tests) passed, "failed" otherwise
+	Status string `json:"status"`
+
+	Stages []Stage `json:"stages"`
+
+	// AntiCheatStatus is "passed" or "failed", omitted if no anti-cheat tests ran (they only run if all stages pass).
+	// Individual anti-cheat tests aren't listed, so that their details stay hidden.
+	AntiCheatStatus string `json:"anti_cheat_status,omitempty"`
+}
+
+// Stage is the result of a single stage
+type Stage struct {
+	// Slug is the stage's slug. Example: "bind-to-port"
+	Slug string `json:"slug"`
+
+	// Title is the stage's title. Example: "Stage #1: Bind to a port"
+	Title string `json:"title"`
+
+	// LogPrefix is the prefix used for the stage's logs. Example: "stage-1"
+	LogPrefix string `json:"log_prefix"`
+
+	// Status is one of "passed", "passed_with_warnings", "failed" or "skipped"
+	Status string `json:"status"`
+
+	DurationInMilliseconds int64 `json:"duration_ms"`
+
+	// Attempts is the number of times the stage was run, more than 1 if it was retried. Omitted if it wasn't run.
+	Attempts int `json:"attempts,omitempty"`
+
+	// FailureMessage is the error that failed the stage, empty if it didn't fail
+	FailureMessage string `json:"failure_message,omitempty"`
+
+	// SkipReason is the reason the test function gave for skipping the stage (see test_case_harness.Skip)
+	SkipReason string `json:"skip_reason,omitempty"`
+
+	// Warnings are the warnings returned by the test function (see test_case_harness.PassWithWarnings)
+	Warnings []string `json:"warnings,omitempty"`
+
+	// SubTests are the results of the stage's sub-tests, if it has any
+	SubTests []SubTest `json:"sub_tests,omitempty"`
+
+	// LogLines are the tester's log lines for the stage (without colors or prefixes)
+	LogLines []LogLine `json:"log_lines"`
+}
+
+// SubTest is the result of one of a stage's sub-tests
+type SubTest struct {
+	// Name is the sub-test's name. Example: "ping-1"
+	Name string `json:"name"`
+
+	// Status is one of "passed", "passed_with_warnings", "failed" or "skipped"
+	Status string `json:"status"`
+
+	DurationInMilliseconds int64 `json:"duration_ms"`
+
+	// FailureMessage is the error that failed the sub-test, empty if it didn't fail
+	FailureMessage string `json:"failure_message,omitempty"`
+
+	// SkipReason is the reason the test function gave for skipping the sub-test
+	SkipReason string `json:"skip_reason,omitempty"`
+
+	// Warnings are the warnings returned by the test function
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// LogLine is a single log line emitted while a stage ran
+type LogLine struct {
+	// Level is one of "debug", "info", "success", "warn", "error", "critical" or "plain"
+	Level string `json:"level"`
+
+	// SecondaryPrefixes are the logger's secondary prefixes at the time of logging. Example: ["client-1"]
+	SecondaryPrefixes []string `json:"secondary_prefixes"`
+
+	Message string `json:"message"`
+}
+
+// NewReport builds a Report from the step results of the stages & anti-cheat tests (nil if they didn't run).
+//
+// Log lines are redacted when they're logged, redactor (nil to disable redaction) is applied to failure messages, skip
+// reasons & warnings.
+func NewReport(stageResults []test_runner.StepResult, antiCheatResults []test_runner.StepResult, isSuccess bool, redactor *logger.Redactor) Report {
+	report := Report{Status: "passed", Stages: []Stage{}}
+	if !isSuccess {
+		report.Status = "failed"
+	}
+
+	for _, stepResult := range stageResults {
+		report.Stages = append(report.Stages, newStage(stepResult, redactor))
+	}
+
+	for _, stepResult := range antiCheatResults {
+		if stepResult.Status == test_runner.FailedStepStatus {
+			report.AntiCheatStatus = "failed"
+			break
+		}
+
+		report.AntiCheatStatus = "passed"
+	}
+
+	return report
+}
+
+// newStage builds a Stage from a step's result
+func newStage(stepResult test_runner.StepResult, redactor *logger.Redactor) Stage {
+	stage := Stage{
+		Slug:                   stepResult.Step.TestCase.Slug,
+		Title:                  stepResult.Step.Title,
+		LogPrefix:              stepResult.Step.TesterLogPrefix,
+		Status:                 string(stepResult.Status),
+		DurationInMilliseconds: stepResult.Duration.Milliseconds(),
+		Attempts:               stepResult.Attempts,
+		LogLines:               []LogLine{},
+	}
+
+	stage.FailureMessage, stage.SkipReason, stage.Warnings = getOutcomeDetails(stepResult.Status, stepResult.Err, redactor)
+
+	for _, subTestResult := range stepResult.SubTestResults {
+		subTest := SubTest{
+			Name:                   subTestResult.Name,
+			Status:                 string(subTestResult.Status),
+			DurationInMilliseconds: subTestResult.Duration.Milliseconds(),
+		}
+
+		subTest.FailureMessage, subTest.SkipReason, subTest.Warnings = getOutcomeDetails(subTestResult.Status, subTestResult.Err, redactor)
+
+		stage.SubTests = append(stage.SubTests, subTest)
+	}
+
+	for _, capturedLine := range stepResult.LogLines {
+		secondaryPrefixes := capturedLine.SecondaryPrefixes
+		if secondaryPrefixes == nil {
+			secondaryPrefixes = []string{}
+		}
+
+		stage.LogLines = append(stage.LogLines, LogLine{
+			Level:             capturedLine.GetEventLevel(),
+			SecondaryPrefixes: secondaryPrefixes,
+			Message:           capturedLine.Message,
+		})
+	}
+
+	return stage
+}
+
+// getOutcomeDetails returns the (redacted) failure message, skip reason & warnings for a stage or sub-test's result
+func getOutcomeDetails(status test_runner.StepStatus, err error, redactor *logger.Redactor) (failureMessage string, skipReason string, warnings []string) {
+	var skipOutcome *test_case_harness.SkipOutcome
+	var passWithWarningsOutcome *test_case_harness.PassWithWarningsOutcome
+
+	redact := func(s string) string {
+		if redactor == nil {
+			return s
+		}
+
+		return redactor.Redact(s)
+	}
+
+	switch {
+	case err == nil:
+	case status == test_runner.FailedStepStatus:
+		failureMessage = redact(err.Error())
+	case errors.As(err, &skipOutcome):
+		skipReason = redact(skipOutcome.Reason)
+	case errors.As(err, &passWithWarningsOutcome) && len(passWithWarningsOutcome.Warnings) > 0:
+		for _, warning := range passWithWarningsOutcome.Warnings {
+			warnings = append(warnings, redact(warning))
+		}
+	}
+
+	return failureMessage, skipReason, warnings
+}
+
+// WriteJSON writes the report to path as JSON
+func (r Report) WriteJSON(path string) error {
+	reportBytes, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(reportBytes, '\n'), 0644)
+}
+
+// getLogText returns a stage's log lines as plain text, like they'd be printed (minus colors)
+func (s Stage) getLogText() string {
+	var builder strings.Builder
+
+	for _, logLine := range s.LogLines {
+		for _, secondaryPrefix := range logLine.SecondaryPrefixes {
+			builder.WriteString("[" + secondaryPrefix + "] ")
+		}
+
+		builder.WriteString(logLine.Message + "\n")
+	}
+
+	return builder.String()
+}
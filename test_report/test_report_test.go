@@ -0,0 +1,147 @@
+package test_report
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-io/tester-utils/logger"
+	"github.com/codecrafters-io/tester-utils/test_runner"
+	"github.com/codecrafters-io/tester-utils/tester_definition"
+	"github.com/stretchr/testify/assert"
+)
+
+func getStepResults() []test_runner.StepResult {
+	return []test_runner.StepResult{
+		{
+			Step: test_runner.TestRunnerStep{
+				TestCase:        tester_definition.TestCase{Slug: "bind"},
+				TesterLogPrefix: "stage-1",
+				Title:           "Stage #1: Bind to a port",
+			},
+			Status:   test_runner.PassedStepStatus,
+			Duration: 1204 * time.Millisecond,
+			LogLines: []logger.CapturedLine{
+				{Level: logger.InfoLevel, Kind: logger.RegularLineKind, Prefix: "[stage-1] ", Message: "Connecting"},
+				{Level: logger.InfoLevel, Kind: logger.SuccessLineKind, Prefix: "[stage-1] ", SecondaryPrefixes: []string{"client"}, Message: "Connected"},
+			},
+		},
+		{
+			Step: test_runner.TestRunnerStep{
+				TestCase:        tester_definition.TestCase{Slug: "ping"},
+				TesterLogPrefix: "stage-2",
+				Title:           "Stage #2: Respond to PING",
+			},
+			Status:   test_runner.FailedStepStatus,
+			Duration: 12 * time.Millisecond,
+			Err:      errors.New("expected \"PONG\", got \"<nil>\""),
+		},
+		{
+			Step: test_runner.TestRunnerStep{
+				TestCase:        tester_definition.TestCase{Slug: "echo"},
+				TesterLogPrefix: "stage-3",
+				Title:           "Stage #3: Implement ECHO",
+			},
+			Status: test_runner.SkippedStepStatus,
+		},
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	reportPath := path.Join(t.TempDir(), "report.json")
+
+	err := NewReport(getStepResults(), nil, false, nil).WriteJSON(reportPath)
+	assert.NoError(t, err)
+
+	reportBytes, err := os.ReadFile(reportPath)
+	assert.NoError(t, err)
+
+	report := map[string]any{}
+	assert.NoError(t, json.Unmarshal(reportBytes, &report))
+
+	assert.Equal(t, "failed", report["status"])
+
+	stages := report["stages"].([]any)
+	assert.Len(t, stages, 3)
+	assert.Equal(t, map[string]any{
+		"slug":        "bind",
+		"title":       "Stage #1: Bind to a port",
+		"log_prefix":  "stage-1",
+		"status":      "passed",
+		"duration_ms": float64(1204),
+		"log_lines": []any{
+			map[string]any{"level": "info", "secondary_prefixes": []any{}, "message": "Connecting"},
+			map[string]any{"level": "success", "secondary_prefixes": []any{"client"}, "message": "Connected"},
+		},
+	}, stages[0])
+	assert.Equal(t, "expected \"PONG\", got \"<nil>\"", stages[1].(map[string]any)["failure_message"])
+	assert.Equal(t, "skipped", stages[2].(map[string]any)["status"])
+}
+
+func TestWriteJUnitXML(t *testing.T) {
+	reportPath := path.Join(t.TempDir(), "report.xml")
+
+	err := NewReport(getStepResults(), nil, false, nil).WriteJUnitXML(reportPath)
+	assert.NoError(t, err)
+
+	reportBytes, err := os.ReadFile(reportPath)
+	assert.NoError(t, err)
+
+	report := string(reportBytes)
+	assert.Contains(t, report, `<testsuites tests="3" failures="1" skipped="1" time="1.216">`)
+	assert.Contains(t, report, `<testcase name="Stage #1: Bind to a port" classname="bind" time="1.204">`)
+	assert.Contains(t, report, "<system-out><![CDATA[Connecting\n[client] Connected\n]]></system-out>")
+	assert.Contains(t, report, `<failure message="expected &#34;PONG&#34;, got &#34;&lt;nil&gt;&#34;">`)
+	assert.Contains(t, report, "<skipped></skipped>")
+}
+
+func TestAntiCheatResults(t *testing.T) {
+	antiCheatResults := []test_runner.StepResult{
+		{
+			Step: test_runner.TestRunnerStep{
+				TestCase:        tester_definition.TestCase{Slug: "anti-cheat-1"},
+				TesterLogPrefix: "ac-1",
+				Title:           "AC1",
+			},
+			Status:   test_runner.FailedStepStatus,
+			Duration: 30 * time.Millisecond,
+			Err:      errors.New("unexpected response"),
+		},
+	}
+
+	report := NewReport(getStepResults()[:1], antiCheatResults, false, nil)
+	assert.Len(t, report.Stages, 1)
+	assert.Equal(t, "failed", report.AntiCheatStatus)
+
+	reportPath := path.Join(t.TempDir(), "report.xml")
+	assert.NoError(t, report.WriteJUnitXML(reportPath))
+
+	reportBytes, err := os.ReadFile(reportPath)
+	assert.NoError(t, err)
+
+	// Only the overall status is reported, anti-cheat details stay hidden
+	xmlReport := string(reportBytes)
+	assert.Contains(t, xmlReport, `<testsuites tests="2" failures="1" skipped="0" time="1.204">`)
+	assert.Contains(t, xmlReport, `<testsuite name="anti-cheat" tests="1" failures="1" skipped="0" time="0.000">`)
+	assert.Contains(t, xmlReport, `<failure message="anti-cheat tests failed">`)
+	assert.NotContains(t, xmlReport, "AC1")
+	assert.NotContains(t, xmlReport, "unexpected response")
+
+	// Anti-cheat tests don't run unless all stages pass, so there's nothing to report
+	reportPath = path.Join(t.TempDir(), "report.json")
+	assert.NoError(t, NewReport(getStepResults(), nil, false, nil).WriteJSON(reportPath))
+
+	reportBytes, err = os.ReadFile(reportPath)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(reportBytes), "anti_cheat")
+}
+
+func TestRedaction(t *testing.T) {
+	redactor := logger.NewRedactor().AddLiteral("PONG", "<response>")
+
+	report := NewReport(getStepResults(), nil, false, redactor)
+	assert.Equal(t, "expected \"<response>\", got \"<nil>\"", report.Stages[1].FailureMessage)
+}
@@ -0,0 +1,80 @@
+package test_runner
+
+import (
+	"time"
+
+	"github.com/codecrafters-io/tester-utils/executable"
+	"github.com/codecrafters-io/tester-utils/logger"
+	"github.com/codecrafters-io/tester-utils/test_case_harness"
+)
+
+// Hooks are run around each of a TestRunner's steps. See tester_definition.TesterDefinition for details on each one.
+type Hooks struct {
+	BeforeEach func(harness *test_case_harness.TestCaseHarness) error
+	AfterEach  func(harness *test_case_harness.TestCaseHarness) error
+
+	// Timeout applies to BeforeEach & AfterEach, separately from the test case's timeout
+	Timeout time.Duration
+}
+
+// SetupHooks are run once per tester run, around all TestRunners (stages & anti-cheat stages). See
+// tester_definition.TesterDefinition for details on each one.
+type SetupHooks struct {
+	BeforeAll func(harness *test_case_harness.TestCaseHarness) error
+	AfterAll  func(harness *test_case_harness.TestCaseHarness) error
+
+	// Timeout applies to BeforeAll & AfterAll
+	Timeout time.Duration
+}
+
+// RunBeforeAll runs the BeforeAll hook (if any) on a new harness, reporting failures. The returned func runs the
+// AfterAll hook (if any) on the same harness, followed by teardown funcs registered during BeforeAll & AfterAll. It must
+// be called once all TestRunners are done, even if BeforeAll failed.
+func (h SetupHooks) RunBeforeAll(isDebug bool, executable *executable.Executable) (runAfterAll func(), err error) {
+	hooksLogger := logger.GetLogger(isDebug, "[tester] ")
+	harness := test_case_harness.NewTestCaseHarness(hooksLogger, executable.Clone())
+
+	runAfterAll = func() {
+		if h.AfterAll != nil {
+			if err := runTestFunc(h.AfterAll, harness, h.Timeout); err != nil {
+				hooksLogger.Debugf("AfterAll hook failed: %s", err)
+			}
+		}
+
+		harness.RunTeardownFuncs()
+	}
+
+	if h.BeforeAll == nil {
+		return runAfterAll, nil
+	}
+
+	if err = runTestFunc(h.BeforeAll, harness, h.Timeout); err != nil {
+		reportTestError(err, "Setup failed", hooksLogger)
+	}
+
+	return runAfterAll, err
+}
+
+// runWithEachHooks calls runTest (which runs the step's test function(s) on the runner's goroutine) between the
+// BeforeEach & AfterEach hooks (if any). The hooks are run on harness, each bounded by Hooks.Timeout, so they don't
+// count towards the test function's timeout.
+//
+// AfterEach is run even if the test function failed, panicked or timed out, but its error is only returned if the
+// test function didn't fail.
+func (r TestRunner) runWithEachHooks(harness *test_case_harness.TestCaseHarness, runTest func() error) error {
+	if r.Hooks.BeforeEach != nil {
+		if err := runTestFunc(r.Hooks.BeforeEach, harness, r.Hooks.Timeout); err != nil {
+			return err
+		}
+	}
+
+	err := runTest()
+
+	if r.Hooks.AfterEach != nil {
+		if afterEachErr := runTestFunc(r.Hooks.AfterEach, harness, r.Hooks.Timeout); afterEachErr != nil && getStepStatus(err) != FailedStepStatus {
+			return afterEachErr
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,53 @@
+package test_runner
+
+import (
+	"sync"
+
+	"github.com/codecrafters-io/tester-utils/executable"
+)
+
+// getParallelBatchSize returns how many steps starting at index can be run together. Only anti-cheat steps (run by
+// quiet runners) can be run in parallel, see tester_definition.TestCase.IsParallelSafe.
+func (r TestRunner) getParallelBatchSize(index int) int {
+	if !r.isQuiet {
+		return 1
+	}
+
+	batchSize := 0
+	for _, step := range r.steps[index:] {
+		if !step.TestCase.IsParallelSafe {
+			break
+		}
+
+		batchSize++
+	}
+
+	return max(batchSize, 1)
+}
+
+// runParallelSteps runs steps concurrently, each with its own logger & executable. Logs are buffered and flushed in the
+// order of the steps once all of them are done, so output doesn't depend on scheduling.
+func (r TestRunner) runParallelSteps(firstIndex int, steps []TestRunnerStep, isDebug bool, executable *executable.Executable) []StepResult {
+	stepResults := make([]StepResult, len(steps))
+	flushFuncs := make([]func(), len(steps))
+
+	var waitGroup sync.WaitGroup
+	for i, step := range steps {
+		stepLogger, flush := r.getLoggerForStep(isDebug, step).WithBufferedOutput()
+		flushFuncs[i] = flush
+
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			stepResults[i] = r.runStep(firstIndex+i, step, stepLogger, executable)
+		}()
+	}
+
+	waitGroup.Wait()
+
+	for _, flush := range flushFuncs {
+		flush()
+	}
+
+	return stepResults
+}
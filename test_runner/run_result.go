@@ -0,0 +1,87 @@
+package test_runner
+
+import (
+	"errors"
+	"time"
+
+	"github.com/codecrafters-io/tester-utils/logger"
+	"github.com/codecrafters-io/tester-utils/test_case_harness"
+)
+
+// StepStatus is the outcome of a single step
+type StepStatus string
+
+const (
+	PassedStepStatus StepStatus = "passed"
+	FailedStepStatus StepStatus = "failed"
+
+	// SkippedStepStatus is used for steps that weren't run because an earlier step failed, and for test functions that
+	// returned test_case_harness.Skip
+	SkippedStepStatus StepStatus = "skipped"
+
+	// PassedWithWarningsStepStatus is used for test functions that returned test_case_harness.PassWithWarnings
+	PassedWithWarningsStepStatus StepStatus = "passed_with_warnings"
+)
+
+// getStepStatus returns the status for an error returned by a test function
+func getStepStatus(err error) StepStatus {
+	var skipOutcome *test_case_harness.SkipOutcome
+	var passWithWarningsOutcome *test_case_harness.PassWithWarningsOutcome
+
+	switch {
+	case err == nil:
+		return PassedStepStatus
+	case errors.As(err, &skipOutcome):
+		return SkippedStepStatus
+	case errors.As(err, &passWithWarningsOutcome):
+		return PassedWithWarningsStepStatus
+	default:
+		return FailedStepStatus
+	}
+}
+
+// StepResult is the outcome of running a TestRunnerStep
+type StepResult struct {
+	Step     TestRunnerStep
+	Status   StepStatus
+	Duration time.Duration
+
+	// Err is the error returned by the test function (or the timeout error), nil if the step passed or wasn't run.
+	//
+	// For skipped and passed-with-warnings steps, this is the outcome (see test_case_harness.SkipOutcome and
+	// test_case_harness.PassWithWarningsOutcome).
+	Err error
+
+	// Attempts is the number of times the step was run, more than 1 if it was retried (see
+	// tester_definition.RetryPolicy). 0 if the step wasn't run.
+	Attempts int
+
+	// SubTestResults has one entry per sub-test, if the step's test case has sub-tests
+	SubTestResults []SubTestResult
+
+	// LogLines are the lines logged while the step ran (excluding program output), subject to the usual level filtering
+	LogLines []logger.CapturedLine
+}
+
+// SubTestResult is the outcome of running a tester_definition.SubTestCase
+type SubTestResult struct {
+	Name     string
+	Status   StepStatus
+	Duration time.Duration
+
+	// Err is the error returned by the test function (or the timeout error), nil if the sub-test passed or wasn't run.
+	// Like in StepResult, this can be an outcome.
+	Err error
+}
+
+// RunResult is the outcome of TestRunner.Run
+type RunResult struct {
+	// IsSuccess is true if all steps passed
+	IsSuccess bool
+
+	// HasInternalError is true if a step failed because of a bug in the tester (like a panic), not in the user's code
+	HasInternalError bool
+
+	// StepResults has one entry per step, in the order they were run
+	StepResults []StepResult
+}
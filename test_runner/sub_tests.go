@@ -0,0 +1,97 @@
+package test_runner
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/codecrafters-io/tester-utils/executable"
+	"github.com/codecrafters-io/tester-utils/logger"
+	"github.com/codecrafters-io/tester-utils/test_case_harness"
+	"github.com/codecrafters-io/tester-utils/tester_definition"
+)
+
+// subTestError is returned for steps that failed because of a sub-test
+type subTestError struct {
+	subTestName string
+	err         error
+}
+
+func (e *subTestError) Error() string {
+	return fmt.Sprintf("%s: %s", e.subTestName, e.err)
+}
+
+func (e *subTestError) Unwrap() error {
+	return e.err
+}
+
+// runSubTestCases runs sub-tests in order until one fails, reporting each one's result. Remaining sub-tests are
+// skipped after a failure.
+//
+// The returned error is a subTestError if a sub-test failed. Otherwise, it's an outcome if all sub-tests were skipped
+// or if any passed with warnings (see test_case_harness.Skip & test_case_harness.PassWithWarnings).
+func (r TestRunner) runSubTestCases(subTestCases []tester_definition.SubTestCase, stepLogger *logger.Logger, executable *executable.Executable) ([]SubTestResult, error) {
+	subTestResults := []SubTestResult{}
+
+	var err error
+	for _, subTestCase := range subTestCases {
+		if err != nil {
+			subTestResults = append(subTestResults, SubTestResult{Name: subTestCase.Name, Status: SkippedStepStatus})
+			continue
+		}
+
+		subTestLogger := stepLogger.Clone()
+		subTestLogger.PushSecondaryPrefix(subTestCase.Name)
+
+		testCaseHarness := test_case_harness.NewTestCaseHarness(subTestLogger, executable.Clone())
+		startTime := time.Now()
+
+		subTestErr := runTestFunc(subTestCase.TestFunc, testCaseHarness, subTestCase.CustomOrDefaultTimeout())
+		r.reportOutcome(subTestErr, "Sub-test", subTestLogger)
+
+		subTestResult := SubTestResult{
+			Name:     subTestCase.Name,
+			Status:   getStepStatus(subTestErr),
+			Duration: time.Since(startTime),
+			Err:      subTestErr,
+		}
+
+		if subTestResult.Status == FailedStepStatus {
+			err = &subTestError{subTestName: subTestCase.Name, err: subTestErr}
+		}
+
+		testCaseHarness.RunTeardownFuncs()
+		subTestResults = append(subTestResults, subTestResult)
+	}
+
+	if err != nil {
+		return subTestResults, err
+	}
+
+	return subTestResults, getRolledUpOutcome(subTestResults)
+}
+
+// getRolledUpOutcome returns the outcome of a step whose sub-tests all passed or were skipped. Warnings were already
+// reported by the sub-tests, so they aren't repeated.
+func getRolledUpOutcome(subTestResults []SubTestResult) error {
+	skippedCount := 0
+	hasWarnings := false
+
+	for _, subTestResult := range subTestResults {
+		switch subTestResult.Status {
+		case SkippedStepStatus:
+			skippedCount++
+		case PassedWithWarningsStepStatus:
+			hasWarnings = true
+		}
+	}
+
+	if skippedCount == len(subTestResults) {
+		return test_case_harness.Skip("all sub-tests were skipped")
+	}
+
+	if hasWarnings {
+		return &test_case_harness.PassWithWarningsOutcome{}
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package test_runner
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/codecrafters-io/tester-utils/logger"
+)
+
+// printSummary prints a table with the status & duration of each step. Used when ShouldContinueOnFailure is set.
+func (r TestRunner) printSummary(isDebug bool, result RunResult) {
+	l := logger.GetLogger(isDebug, "")
+	l.BlankLine()
+	l.Infof("Summary:")
+
+	rows := r.getSummaryRows(result)
+	for index, stepResult := range result.StepResults {
+		switch stepResult.Status {
+		case PassedStepStatus:
+			l.Successln(rows[index])
+		case PassedWithWarningsStepStatus:
+			l.Warnln(rows[index])
+		case FailedStepStatus:
+			l.Errorln(rows[index])
+		default:
+			l.Infoln(rows[index])
+		}
+	}
+
+	l.Infof("%s", getSummaryCounts(result))
+}
+
+// getSummaryRows returns one aligned row per step. Example: "Stage #1: Bind to a port  passed  0.012s"
+func (r TestRunner) getSummaryRows(result RunResult) []string {
+	buffer := bytes.NewBuffer([]byte{})
+	tabWriter := tabwriter.NewWriter(buffer, 0, 0, 2, ' ', 0)
+
+	for _, stepResult := range result.StepResults {
+		// Steps that weren't run don't have a duration (steps skipped by their test function do)
+		duration := "-"
+		if stepResult.Status != SkippedStepStatus || stepResult.Err != nil {
+			duration = stepResult.Duration.Round(time.Millisecond).String()
+		}
+
+		status := strings.ReplaceAll(string(stepResult.Status), "_", " ")
+		fmt.Fprintf(tabWriter, "%s\t%s\t%s\n", stepResult.Step.Title, status, duration)
+	}
+
+	tabWriter.Flush()
+
+	return strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n")
+}
+
+// getSummaryCounts returns a line like "3 passed, 1 failed, 0 skipped". Steps that passed with warnings are only
+// mentioned if there are any: "2 passed, 1 passed with warnings, 1 failed, 0 skipped"
+func getSummaryCounts(result RunResult) string {
+	counts := map[StepStatus]int{}
+	for _, stepResult := range result.StepResults {
+		counts[stepResult.Status]++
+	}
+
+	parts := []string{fmt.Sprintf("%d passed", counts[PassedStepStatus])}
+	if counts[PassedWithWarningsStepStatus] > 0 {
+		parts = append(parts, fmt.Sprintf("%d passed with warnings", counts[PassedWithWarningsStepStatus]))
+	}
+
+	parts = append(parts, fmt.Sprintf("%d failed", counts[FailedStepStatus]), fmt.Sprintf("%d skipped", counts[SkippedStepStatus]))
+
+	return strings.Join(parts, ", ")
+}
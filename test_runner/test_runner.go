@@ -1,10 +1,14 @@
 package test_runner
 
 import (
+	"errors"
 	"fmt"
+	"runtime/debug"
+	"strings"
 	"time"
 
 	"github.com/codecrafters-io/tester-utils/executable"
+	"github.com/codecrafters-io/tester-utils/internal"
 	"github.com/codecrafters-io/tester-utils/logger"
 	"github.com/codecrafters-io/tester-utils/test_case_harness"
 	"github.com/codecrafters-io/tester-utils/tester_definition"
@@ -23,6 +27,12 @@ type TestRunnerStep struct {
 
 // testRunner is used to run multiple tests
 type TestRunner struct {
+	// ShouldContinueOnFailure makes Run run all steps (instead of stopping at the first failure) and print a summary
+	ShouldContinueOnFailure bool
+
+	// Hooks are run around the steps (see Hooks)
+	Hooks Hooks
+
 	isQuiet bool // Used for anti-cheat tests, where we only want Critical logs to be emitted
 	steps   []TestRunnerStep
 }
@@ -38,65 +48,268 @@ func NewQuietTestRunner(steps []TestRunnerStep) TestRunner {
 }
 
 // Run runs all tests in a stageRunner
-func (r TestRunner) Run(isDebug bool, executable *executable.Executable) bool {
-	for index, step := range r.steps {
-		if index != 0 {
-			fmt.Println("")
+func (r TestRunner) Run(isDebug bool, executable *executable.Executable) RunResult {
+	result := RunResult{IsSuccess: true}
+
+	for index := 0; index < len(r.steps); {
+		batch := r.steps[index : index+r.getParallelBatchSize(index)]
+
+		var stepResults []StepResult
+		if !result.IsSuccess && !r.ShouldContinueOnFailure {
+			for _, step := range batch {
+				stepResults = append(stepResults, StepResult{Step: step, Status: SkippedStepStatus})
+			}
+		} else if len(batch) == 1 {
+			stepResults = []StepResult{r.runStep(index, batch[0], r.getLoggerForStep(isDebug, batch[0]), executable)}
+		} else {
+			stepResults = r.runParallelSteps(index, batch, isDebug, executable)
 		}
 
-		testCaseHarness := test_case_harness.TestCaseHarness{
-			Logger:     r.getLoggerForStep(isDebug, step),
-			Executable: executable.Clone(),
+		for _, stepResult := range stepResults {
+			result.StepResults = append(result.StepResults, stepResult)
+
+			if stepResult.Status == FailedStepStatus {
+				result.IsSuccess = false
+			}
+
+			var panicError *internal.PanicError
+			if errors.As(stepResult.Err, &panicError) {
+				result.HasInternalError = true
+			}
 		}
 
-		logger := testCaseHarness.Logger
-		logger.Infof("Running tests for %s", step.Title)
+		index += len(batch)
+	}
 
-		stepResultChannel := make(chan error, 1)
-		go func() {
-			err := step.TestCase.TestFunc(&testCaseHarness)
-			stepResultChannel <- err
-		}()
+	if r.ShouldContinueOnFailure {
+		r.printSummary(isDebug, result)
+	}
 
-		timeout := step.TestCase.CustomOrDefaultTimeout()
+	return result
+}
 
-		var err error
-		select {
-		case stageErr := <-stepResultChannel:
-			err = stageErr
-		case <-time.After(timeout):
-			err = fmt.Errorf("timed out, test exceeded %d seconds", int64(timeout.Seconds()))
-		}
+// SkipAll returns the result of a run in which no steps were run because of err (like a failed BeforeAll hook, see
+// SetupHooks). Steps are skipped even with ShouldContinueOnFailure.
+func (r TestRunner) SkipAll(err error) RunResult {
+	var panicError *internal.PanicError
+	result := RunResult{IsSuccess: false, HasInternalError: errors.As(err, &panicError)}
 
-		if err != nil {
-			r.reportTestError(err, isDebug, logger)
-		} else {
-			logger.Successf("Test passed.")
-		}
+	for _, step := range r.steps {
+		result.StepResults = append(result.StepResults, StepResult{Step: step, Status: SkippedStepStatus})
+	}
+
+	return result
+}
+
+// runStep runs a single step, reporting the result to the user via stepLogger (see getLoggerForStep)
+func (r TestRunner) runStep(index int, step TestRunnerStep, stepLogger *logger.Logger, executable *executable.Executable) StepResult {
+	// Elapsed timestamps (if enabled) are relative to the start of the stage
+	logger.ResetTimestampClock()
+
+	// Harnesses get a clone of this logger, which is silenced if the test function times out. All clones record lines
+	// for StepResult.LogLines.
+	logger := stepLogger.WithLineCapture()
 
-		testCaseHarness.RunTeardownFuncs()
+	if index != 0 {
+		logger.BlankLine()
+	}
+
+	r.emitStageStartedEvent(logger, step)
+	logger.Infof("Running tests for %s", step.Title)
+	startTime := time.Now()
+
+	var err error
+	var subTestResults []SubTestResult
+	runTeardownFuncs := func() {}
+	attempts := 0
+
+	for {
+		attempts++
+
+		// For stages with sub-tests, this harness is only used by the BeforeEach & AfterEach hooks
+		testCaseHarness := test_case_harness.NewTestCaseHarness(logger, executable.Clone())
 
-		if err != nil {
-			return false
+		err = r.runWithEachHooks(testCaseHarness, func() error {
+			if len(step.TestCase.SubTestCases) == 0 {
+				return runTestFunc(step.TestCase.TestFunc, testCaseHarness, step.TestCase.CustomOrDefaultTimeout())
+			}
+
+			var subTestsErr error
+			subTestResults, subTestsErr = r.runSubTestCases(step.TestCase.SubTestCases, logger, executable)
+
+			return subTestsErr
+		})
+
+		runTeardownFuncs = testCaseHarness.RunTeardownFuncs
+
+		if !step.TestCase.RetryPolicy.ShouldRetry(err, attempts) {
+			break
 		}
+
+		r.reportRetry(err, attempts, step.TestCase.RetryPolicy.MaxAttempts, logger)
+		runTeardownFuncs()
 	}
 
-	return true
+	duration := time.Since(startTime)
+
+	status := getStepStatus(err)
+
+	var subTestErr *subTestError
+	if errors.As(err, &subTestErr) {
+		// The sub-test's error was reported when it failed
+		reportFailure(err, "Test failed", logger)
+	} else {
+		r.reportOutcome(err, "Test", logger)
+	}
+
+	r.emitStageFinishedEvent(logger, step, status, err, duration)
+
+	runTeardownFuncs()
+
+	return StepResult{
+		Step:           step,
+		Status:         status,
+		Duration:       duration,
+		Err:            err,
+		Attempts:       attempts,
+		SubTestResults: subTestResults,
+		LogLines:       logger.GetCapturedLines(),
+	}
 }
 
+// runTestFunc runs a test function on its own goroutine, recovering panics. If the timeout is exceeded, the harness is
+// cancelled so that the test function can't interfere with what comes next.
+func runTestFunc(testFunc func(*test_case_harness.TestCaseHarness) error, testCaseHarness *test_case_harness.TestCaseHarness, timeout time.Duration) error {
+	resultChannel := make(chan error, 1)
+
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				resultChannel <- &internal.PanicError{Value: recovered, Stack: debug.Stack()}
+			}
+		}()
+
+		resultChannel <- testFunc(testCaseHarness)
+	}()
+
+	select {
+	case err := <-resultChannel:
+		return err
+	case <-time.After(timeout):
+		testCaseHarness.Cancel()
+		return &internal.TimeoutError{Timeout: timeout}
+	}
+}
+
+// getLoggerForStep returns the logger passed to the step's TestFunc.
+//
+// Test functions don't need to know which kind of runner they're in: all logging methods work with both loggers, quiet
+// loggers just drop everything except critical logs (see logger.Logger.Criticalf).
 func (r TestRunner) getLoggerForStep(isDebug bool, step TestRunnerStep) *logger.Logger {
 	if r.isQuiet {
-		return logger.GetQuietLogger("")
-	} else {
-		return logger.GetLogger(isDebug, fmt.Sprintf("[%s] ", step.TesterLogPrefix))
+		quietLogger := logger.GetQuietLogger("")
+		quietLogger.IsDebug = isDebug // Doesn't emit debug logs, but lets reportTestError show stack traces
+
+		return quietLogger
 	}
+
+	return logger.GetLogger(isDebug, fmt.Sprintf("[%s] ", step.TesterLogPrefix))
 }
 
-func (r TestRunner) reportTestError(err error, isDebug bool, logger *logger.Logger) {
-	if err.Error() != "" {
+func (r TestRunner) emitStageStartedEvent(l *logger.Logger, step TestRunnerStep) {
+	l.EmitEvent(logger.Event{
+		Type:       logger.StageStartedEventType,
+		Prefix:     step.TesterLogPrefix,
+		StageSlug:  step.TestCase.Slug,
+		StageTitle: step.Title,
+	})
+}
+
+func (r TestRunner) emitStageFinishedEvent(l *logger.Logger, step TestRunnerStep, status StepStatus, err error, duration time.Duration) {
+	event := logger.Event{
+		Type:                   logger.StageFinishedEventType,
+		Prefix:                 step.TesterLogPrefix,
+		StageSlug:              step.TestCase.Slug,
+		StageTitle:             step.Title,
+		Status:                 string(status),
+		DurationInMilliseconds: duration.Milliseconds(),
+	}
+
+	var skipOutcome *test_case_harness.SkipOutcome
+	var passWithWarningsOutcome *test_case_harness.PassWithWarningsOutcome
+
+	switch {
+	case status == FailedStepStatus:
+		event.Error = err.Error()
+	case errors.As(err, &skipOutcome):
+		event.Message = skipOutcome.Reason
+	case errors.As(err, &passWithWarningsOutcome):
+		event.Warnings = passWithWarningsOutcome.Warnings
+	}
+
+	l.EmitEvent(event)
+}
+
+// reportOutcome logs the result of a test function. label is "Test" for stages, "Sub-test" for sub-tests.
+func (r TestRunner) reportOutcome(err error, label string, logger *logger.Logger) {
+	var skipOutcome *test_case_harness.SkipOutcome
+	var passWithWarningsOutcome *test_case_harness.PassWithWarningsOutcome
+
+	switch {
+	case err == nil:
+		logger.Successf("%s passed.", label)
+	case errors.As(err, &skipOutcome):
+		logger.Warnf("%s skipped: %s", label, skipOutcome.Reason)
+	case errors.As(err, &passWithWarningsOutcome):
+		for _, warning := range passWithWarningsOutcome.Warnings {
+			logger.Warnf("Warning: %s", warning)
+		}
+
+		logger.Successf("%s passed with warnings.", label)
+	default:
+		reportTestError(err, label+" failed", logger)
+	}
+}
+
+// reportRetry logs a failed attempt for steps with a retry policy
+func (r TestRunner) reportRetry(err error, attempt int, maxAttempts int, logger *logger.Logger) {
+	// Sub-test errors were reported when the sub-test failed
+	var subTestErr *subTestError
+	if !errors.As(err, &subTestErr) {
+		logger.Errorf("%s", err)
+	}
+
+	logger.Warnf("Attempt %d of %d failed, retrying...", attempt, maxAttempts)
+}
+
+// reportTestError logs err, followed by failureMessage (like "Test failed"). Panics are always shown (even in
+// anti-cheat stages), with the stack trace in debug mode.
+func reportTestError(err error, failureMessage string, logger *logger.Logger) {
+	var panicError *internal.PanicError
+	if errors.As(err, &panicError) {
+		logger.Criticalf("%s", panicError)
+
+		// Quiet loggers drop debug logs, but stack traces are needed to fix the tester
+		if logger.IsQuiet && logger.IsDebug {
+			logger.Criticalf("%s", strings.TrimSpace(string(panicError.Stack)))
+		} else {
+			logger.Debugf("%s", strings.TrimSpace(string(panicError.Stack)))
+		}
+	} else if err.Error() != "" {
 		logger.Errorf("%s", err)
 	}
-	logger.Errorf("Test failed")
+
+	reportFailure(err, failureMessage, logger)
+}
+
+// reportFailure logs failureMessage. Like in reportTestError, failures caused by panics are always shown.
+func reportFailure(err error, failureMessage string, logger *logger.Logger) {
+	var panicError *internal.PanicError
+	if errors.As(err, &panicError) {
+		logger.Criticalf("%s", failureMessage)
+	} else {
+		logger.Errorf("%s", failureMessage)
+	}
 }
 
 // Fuck you, go
@@ -2,11 +2,14 @@ package tester_utils
 
 import (
 	"fmt"
+	"os"
 
+	"github.com/codecrafters-io/tester-utils/color_policy"
 	"github.com/codecrafters-io/tester-utils/executable"
 	"github.com/codecrafters-io/tester-utils/internal"
 	"github.com/codecrafters-io/tester-utils/logger"
 	"github.com/codecrafters-io/tester-utils/random"
+	"github.com/codecrafters-io/tester-utils/test_report"
 	"github.com/codecrafters-io/tester-utils/test_runner"
 	"github.com/codecrafters-io/tester-utils/tester_context"
 	"github.com/codecrafters-io/tester-utils/tester_definition"
@@ -22,7 +25,7 @@ func newTester(env map[string]string, definition tester_definition.TesterDefinit
 	context, err := tester_context.GetTesterContext(env, definition)
 	if err != nil {
 		if userError, ok := err.(*internal.UserError); ok {
-			return Tester{}, fmt.Errorf("%s", userError.Message)
+			return Tester{}, userError
 		}
 
 		return Tester{}, fmt.Errorf("CodeCrafters internal error. Error fetching tester context: %v", err)
@@ -40,49 +43,173 @@ func newTester(env map[string]string, definition tester_definition.TesterDefinit
 	return tester, nil
 }
 
-// RunCLI executes the tester based on user-provided env vars
+// InternalErrorExitCode is returned by RunCLI when a test fails because of a bug in the tester (like a panic in a
+// test function), rather than because of the user's code.
+const InternalErrorExitCode = 2
+
+// RunCLI executes the tester based on user-provided env vars. Returns the exit code: 0 if all tests pass, 1 if a test
+// fails and InternalErrorExitCode if the tester itself failed.
 func RunCLI(env map[string]string, definition tester_definition.TesterDefinition) int {
 	random.Init()
 
 	tester, err := newTester(env, definition)
 	if err != nil {
-		fmt.Println(err.Error())
-		return 1
+		// The context couldn't be parsed, but the output format is still honored so that JSON output stays valid
+		if logger.OutputFormat(env["CODECRAFTERS_OUTPUT_FORMAT"]) == logger.JSONOutputFormat {
+			logger.SetDefaultOutputFormat(logger.JSONOutputFormat)
+			defer logger.SetDefaultOutputFormat(logger.TextOutputFormat)
+		}
+
+		tester.reportInternalError("%s", err)
+
+		// User errors (like an invalid codecrafters.yml) are the user's to fix
+		if _, ok := err.(*internal.UserError); ok {
+			return 1
+		}
+
+		return InternalErrorExitCode
+	}
+
+	logger.SetDefaultOutputFormat(tester.context.OutputFormat)
+	defer logger.SetDefaultOutputFormat(logger.TextOutputFormat)
+
+	color_policy.Set(tester.context.ColorPolicy)
+	defer color_policy.Set(color_policy.DefaultColorPolicy)
+
+	logger.SetDefaultTimestampMode(tester.context.TimestampMode)
+	defer logger.SetDefaultTimestampMode(logger.NoTimestamps)
+
+	logger.SetDefaultGroupStyle(tester.context.GroupStyle)
+	defer logger.SetDefaultGroupStyle(logger.PlainGroupStyle)
+
+	redactor := tester.getLogRedactor()
+	logger.SetDefaultRedactor(redactor)
+	defer logger.SetDefaultRedactor(nil)
+
+	if tester.context.FullLogPath != "" {
+		fullLogFile, err := os.Create(tester.context.FullLogPath)
+		if err != nil {
+			tester.reportInternalError("CodeCrafters internal error. Error creating full log file: %v", err)
+			return InternalErrorExitCode
+		}
+		defer fullLogFile.Close()
+
+		logger.SetDefaultFullLogWriter(fullLogFile)
+		defer logger.SetDefaultFullLogWriter(nil)
 	}
 
 	tester.printDebugContext()
 
 	// TODO: Validate context here instead of in NewTester?
 
-	if !tester.runStages() {
-		return 1
+	stagesResult, antiCheatResult := tester.runAllStages()
+
+	exitCode := getExitCode(stagesResult)
+	if exitCode == 0 {
+		exitCode = getExitCode(antiCheatResult)
 	}
 
-	if !tester.context.ShouldSkipAntiCheatTestCases && !tester.runAntiCheatStages() {
-		return 1
+	tester.emitResultEvent(exitCode == 0)
+
+	if err := tester.writeReports(stagesResult, antiCheatResult, exitCode == 0, redactor); err != nil {
+		tester.reportInternalError("CodeCrafters internal error. Error writing test report: %v", err)
+		return InternalErrorExitCode
+	}
+
+	return exitCode
+}
+
+// getExitCode lets the platform tell tester bugs apart from failures caused by the user's code
+func getExitCode(result test_runner.RunResult) int {
+	if result.IsSuccess {
+		return 0
+	}
+
+	if result.HasInternalError {
+		return InternalErrorExitCode
 	}
 
-	return 0
+	return 1
 }
 
-// PrintDebugContext is to be run as early as possible after creating a Tester
+// writeReports writes the test reports requested via the tester context (if any), redacted like logs
+func (tester Tester) writeReports(stagesResult test_runner.RunResult, antiCheatResult test_runner.RunResult, isSuccess bool, redactor *logger.Redactor) error {
+	report := test_report.NewReport(stagesResult.StepResults, antiCheatResult.StepResults, isSuccess, redactor)
+
+	if tester.context.JSONReportPath != "" {
+		if err := report.WriteJSON(tester.context.JSONReportPath); err != nil {
+			return err
+		}
+	}
+
+	if tester.context.JUnitReportPath != "" {
+		if err := report.WriteJUnitXML(tester.context.JUnitReportPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reportInternalError logs an error caused by the tester (or its environment) rather than the user's code. Like other
+// logs, it is written as an event in JSON mode.
+func (tester Tester) reportInternalError(fstring string, args ...any) {
+	logger.GetLogger(tester.context.IsDebug, "").Errorf(fstring, args...)
+}
+
+// emitResultEvent emits the final result when using the JSON output format
+func (tester Tester) emitResultEvent(passed bool) {
+	status := "passed"
+	if !passed {
+		status = "failed"
+	}
+
+	logger.GetLogger(tester.context.IsDebug, "").EmitEvent(logger.Event{
+		Type:   logger.ResultEventType,
+		Status: status,
+	})
+}
+
+// printDebugContext is to be run as early as possible after creating a Tester
 func (tester Tester) printDebugContext() {
-	if !tester.context.IsDebug {
+	// JSON output must only contain events
+	if !tester.context.IsDebug || tester.context.OutputFormat == logger.JSONOutputFormat {
 		return
 	}
 
 	tester.context.Print()
-	fmt.Println("")
+	logger.GetLogger(tester.context.IsDebug, "").BlankLine()
+}
+
+// runAllStages runs the stages, followed by the anti-cheat stages if all stages passed. The BeforeAll & AfterAll hooks
+// are run once, around both. If the anti-cheat stages aren't run, their result is a success with no steps.
+func (tester Tester) runAllStages() (stagesResult test_runner.RunResult, antiCheatResult test_runner.RunResult) {
+	antiCheatResult = test_runner.RunResult{IsSuccess: true}
+
+	runAfterAll, err := tester.getSetupHooks().RunBeforeAll(tester.context.IsDebug, tester.getExecutable())
+	defer runAfterAll()
+
+	if err != nil {
+		return tester.getRunner().SkipAll(err), antiCheatResult
+	}
+
+	stagesResult = tester.runStages()
+
+	if stagesResult.IsSuccess && !tester.context.ShouldSkipAntiCheatTestCases {
+		antiCheatResult = tester.runAntiCheatStages()
+	}
+
+	return stagesResult, antiCheatResult
 }
 
 // runAntiCheatStages runs any anti-cheat stages specified in the TesterDefinition. Only critical logs are emitted. If
 // the stages pass, the user won't see any visible output.
-func (tester Tester) runAntiCheatStages() bool {
-	return tester.getAntiCheatRunner().Run(false, tester.getQuietExecutable())
+func (tester Tester) runAntiCheatStages() test_runner.RunResult {
+	return tester.getAntiCheatRunner().Run(tester.context.IsDebug, tester.getQuietExecutable())
 }
 
-// runStages runs all the stages upto the current stage the user is attempting. Returns true if all stages pass.
-func (tester Tester) runStages() bool {
+// runStages runs all the stages upto the current stage the user is attempting.
+func (tester Tester) runStages() test_runner.RunResult {
 	return tester.getRunner().Run(tester.context.IsDebug, tester.getExecutable())
 }
 
@@ -99,7 +226,11 @@ func (tester Tester) getRunner() test_runner.TestRunner {
 		})
 	}
 
-	return test_runner.NewTestRunner(steps)
+	runner := test_runner.NewTestRunner(steps)
+	runner.ShouldContinueOnFailure = tester.context.ShouldContinueOnFailure
+	runner.Hooks = tester.getHooks()
+
+	return runner
 }
 
 func (tester Tester) getAntiCheatRunner() test_runner.TestRunner {
@@ -113,7 +244,36 @@ func (tester Tester) getAntiCheatRunner() test_runner.TestRunner {
 		})
 	}
 
-	return test_runner.NewQuietTestRunner(steps) // We only want Critical logs to be emitted for anti-cheat tests
+	runner := test_runner.NewQuietTestRunner(steps) // We only want Critical logs to be emitted for anti-cheat tests
+	runner.Hooks = tester.getHooks()
+
+	return runner
+}
+
+func (tester Tester) getHooks() test_runner.Hooks {
+	return test_runner.Hooks{
+		BeforeEach: tester.definition.BeforeEach,
+		AfterEach:  tester.definition.AfterEach,
+		Timeout:    tester.definition.CustomOrDefaultHookTimeout(),
+	}
+}
+
+func (tester Tester) getSetupHooks() test_runner.SetupHooks {
+	return test_runner.SetupHooks{
+		BeforeAll: tester.definition.BeforeAll,
+		AfterAll:  tester.definition.AfterAll,
+		Timeout:   tester.definition.CustomOrDefaultHookTimeout(),
+	}
+}
+
+// getLogRedactor returns the tester's Redactor (if any), extended to redact secrets from the environment
+func (tester Tester) getLogRedactor() *logger.Redactor {
+	redactor := logger.NewRedactor()
+	if tester.definition.LogRedactor != nil {
+		redactor = tester.definition.LogRedactor.Clone()
+	}
+
+	return redactor.AddSecretsFromEnvironment()
 }
 
 func (tester Tester) getQuietExecutable() *executable.Executable {
@@ -121,7 +281,12 @@ func (tester Tester) getQuietExecutable() *executable.Executable {
 }
 
 func (tester Tester) getExecutable() *executable.Executable {
-	return executable.NewVerboseExecutable(tester.context.ExecutablePath, logger.GetLogger(true, "[your_program] ").Plainln)
+	programLogger := logger.GetLogger(true, "[your_program] ")
+
+	e := executable.NewVerboseExecutable(tester.context.ExecutablePath, programLogger.ProgramOutputln)
+	e.TruncatedOutputLoggerFunc = programLogger.FullLogOnlyln
+
+	return e
 }
 
 func (tester Tester) validateContext() error {
@@ -7,7 +7,9 @@ import (
 	"os"
 	"path"
 
+	"github.com/codecrafters-io/tester-utils/color_policy"
 	"github.com/codecrafters-io/tester-utils/internal"
+	"github.com/codecrafters-io/tester-utils/logger"
 	"github.com/codecrafters-io/tester-utils/tester_definition"
 	"gopkg.in/yaml.v2"
 )
@@ -30,14 +32,43 @@ type TesterContext struct {
 	IsDebug                      bool
 	TestCases                    []TesterContextTestCase
 	ShouldSkipAntiCheatTestCases bool
+
+	// OutputFormat is read from CODECRAFTERS_OUTPUT_FORMAT ("text" or "json"). Defaults to text.
+	OutputFormat logger.OutputFormat
+
+	// ColorPolicy is read from CODECRAFTERS_COLOR ("always", "never" or "auto"). Defaults to color_policy.DefaultColorPolicy.
+	ColorPolicy color_policy.ColorPolicy
+
+	// GroupStyle is read from CODECRAFTERS_LOG_GROUP_STYLE ("indented" or "github_actions"). Defaults to plain.
+	GroupStyle logger.GroupStyle
+
+	// TimestampMode is read from the timestamps key in codecrafters.yml ("elapsed" or "delta"). Defaults to none.
+	TimestampMode logger.TimestampMode
+
+	// ShouldContinueOnFailure is read from CODECRAFTERS_CONTINUE_ON_FAILURE ("true" to enable). If set, all stages are
+	// run even if one fails, followed by a summary.
+	ShouldContinueOnFailure bool
+
+	// JUnitReportPath is read from CODECRAFTERS_JUNIT_REPORT_PATH. If set, a JUnit XML report is written to this file.
+	JUnitReportPath string
+
+	// JSONReportPath is read from CODECRAFTERS_JSON_REPORT_PATH. If set, a JSON report (see test_report.Report) is
+	// written to this file.
+	JSONReportPath string
+
+	// FullLogPath is read from CODECRAFTERS_FULL_LOG_PATH. If set, a complete log (including debug logs and truncated
+	// program output) is written to this file.
+	FullLogPath string
 }
 
 type yamlConfig struct {
-	Debug bool `yaml:"debug"`
+	Debug      bool   `yaml:"debug"`
+	Timestamps string `yaml:"timestamps"`
 }
 
+// Print logs the context using the default logger (see logger.SetDefaultWriter)
 func (c TesterContext) Print() {
-	fmt.Println("Debug =", c.IsDebug)
+	logger.GetLogger(c.IsDebug, "").Plainf("Debug = %v", c.IsDebug)
 }
 
 // GetContext parses flags and returns a Context object
@@ -64,6 +95,40 @@ func GetTesterContext(env map[string]string, definition tester_definition.Tester
 		shouldSkipAntiCheatTestCases = true
 	}
 
+	var shouldContinueOnFailure = false
+
+	continueOnFailureValue, ok := env["CODECRAFTERS_CONTINUE_ON_FAILURE"]
+	if ok && continueOnFailureValue == "true" {
+		shouldContinueOnFailure = true
+	}
+
+	outputFormat := logger.TextOutputFormat
+
+	if outputFormatValue, ok := env["CODECRAFTERS_OUTPUT_FORMAT"]; ok && outputFormatValue != "" {
+		switch logger.OutputFormat(outputFormatValue) {
+		case logger.TextOutputFormat, logger.JSONOutputFormat:
+			outputFormat = logger.OutputFormat(outputFormatValue)
+		default:
+			return TesterContext{}, fmt.Errorf("CODECRAFTERS_OUTPUT_FORMAT must be one of text, json (got %q)", outputFormatValue)
+		}
+	}
+
+	colorPolicy := color_policy.DefaultColorPolicy
+
+	if colorPolicyValue, ok := env["CODECRAFTERS_COLOR"]; ok && colorPolicyValue != "" {
+		parsedColorPolicy, err := color_policy.Parse(colorPolicyValue)
+		if err != nil {
+			return TesterContext{}, fmt.Errorf("CODECRAFTERS_COLOR is invalid: %s", err)
+		}
+
+		colorPolicy = parsedColorPolicy
+	}
+
+	groupStyle, err := logger.ParseGroupStyle(env["CODECRAFTERS_LOG_GROUP_STYLE"])
+	if err != nil {
+		return TesterContext{}, fmt.Errorf("CODECRAFTERS_LOG_GROUP_STYLE is invalid: %s", err)
+	}
+
 	for _, testCase := range testCases {
 		if testCase.Slug == "" {
 			return TesterContext{}, fmt.Errorf("CODECRAFTERS_TEST_CASES_JSON contains a test case with an empty slug")
@@ -104,6 +169,13 @@ func GetTesterContext(env map[string]string, definition tester_definition.Tester
 		return TesterContext{}, fmt.Errorf("CODECRAFTERS_TEST_CASES is empty")
 	}
 
+	timestampMode, err := logger.ParseTimestampMode(yamlConfig.Timestamps)
+	if err != nil {
+		return TesterContext{}, &internal.UserError{
+			Message: fmt.Sprintf("Error parsing codecrafters.yml: %s", err),
+		}
+	}
+
 	// TODO: test if executable exists?
 
 	return TesterContext{
@@ -111,6 +183,14 @@ func GetTesterContext(env map[string]string, definition tester_definition.Tester
 		IsDebug:                      yamlConfig.Debug,
 		TestCases:                    testCases,
 		ShouldSkipAntiCheatTestCases: shouldSkipAntiCheatTestCases,
+		OutputFormat:                 outputFormat,
+		ColorPolicy:                  colorPolicy,
+		TimestampMode:                timestampMode,
+		GroupStyle:                   groupStyle,
+		ShouldContinueOnFailure:      shouldContinueOnFailure,
+		FullLogPath:                  env["CODECRAFTERS_FULL_LOG_PATH"],
+		JUnitReportPath:              env["CODECRAFTERS_JUNIT_REPORT_PATH"],
+		JSONReportPath:               env["CODECRAFTERS_JSON_REPORT_PATH"],
 	}, nil
 }
 
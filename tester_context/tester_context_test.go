@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/codecrafters-io/tester-utils/logger"
 	"github.com/codecrafters-io/tester-utils/tester_definition"
 	"github.com/stretchr/testify/assert"
 )
@@ -67,3 +68,55 @@ func TestCorrectExecutable(t *testing.T) {
 		assert.Equal(t, context.ExecutablePath, fmt.Sprintf("test_helpers/%s/%s", tt.submissionDir, tt.expectedExecutable))
 	}
 }
+
+func TestOutputFormat(t *testing.T) {
+	context, err := GetTesterContext(map[string]string{
+		"CODECRAFTERS_TEST_CASES_JSON": `[{ "slug": "test", "tester_log_prefix": "test", "title": "Test"}]`,
+		"CODECRAFTERS_REPOSITORY_DIR":  "./test_helpers/valid_app_dir",
+	}, tester_definition.TesterDefinition{})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	assert.Equal(t, logger.TextOutputFormat, context.OutputFormat)
+
+	context, err = GetTesterContext(map[string]string{
+		"CODECRAFTERS_TEST_CASES_JSON": `[{ "slug": "test", "tester_log_prefix": "test", "title": "Test"}]`,
+		"CODECRAFTERS_REPOSITORY_DIR":  "./test_helpers/valid_app_dir",
+		"CODECRAFTERS_OUTPUT_FORMAT":   "json",
+	}, tester_definition.TesterDefinition{})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	assert.Equal(t, logger.JSONOutputFormat, context.OutputFormat)
+
+	_, err = GetTesterContext(map[string]string{
+		"CODECRAFTERS_TEST_CASES_JSON": `[{ "slug": "test", "tester_log_prefix": "test", "title": "Test"}]`,
+		"CODECRAFTERS_REPOSITORY_DIR":  "./test_helpers/valid_app_dir",
+		"CODECRAFTERS_OUTPUT_FORMAT":   "xml",
+	}, tester_definition.TesterDefinition{})
+	assert.Error(t, err)
+}
+
+func TestTimestampMode(t *testing.T) {
+	context, err := GetTesterContext(map[string]string{
+		"CODECRAFTERS_TEST_CASES_JSON": `[{ "slug": "test", "tester_log_prefix": "test", "title": "Test"}]`,
+		"CODECRAFTERS_REPOSITORY_DIR":  "./test_helpers/valid_app_dir",
+	}, tester_definition.TesterDefinition{})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	assert.Equal(t, logger.NoTimestamps, context.TimestampMode)
+
+	context, err = GetTesterContext(map[string]string{
+		"CODECRAFTERS_TEST_CASES_JSON": `[{ "slug": "test", "tester_log_prefix": "test", "title": "Test"}]`,
+		"CODECRAFTERS_REPOSITORY_DIR":  "./test_helpers/valid_app_dir_timestamps",
+	}, tester_definition.TesterDefinition{})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	assert.Equal(t, logger.ElapsedTimestamps, context.TimestampMode)
+}
@@ -0,0 +1,72 @@
+package tester_definition
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"syscall"
+
+	"github.com/codecrafters-io/tester-utils/internal"
+)
+
+// RetryableErrorClass is a kind of error that a RetryPolicy can retry on
+type RetryableErrorClass string
+
+const (
+	// TimeoutErrorClass matches test functions that exceeded their timeout, as well as network & context deadlines
+	TimeoutErrorClass RetryableErrorClass = "timeout"
+
+	// ConnectionRefusedErrorClass matches ECONNREFUSED, usually seen when the user's program is slow to start listening
+	ConnectionRefusedErrorClass RetryableErrorClass = "connection_refused"
+)
+
+// RetryPolicy lets a test case be re-run when it fails in ways that overloaded machines can cause (see
+// TestCase.RetryPolicy). Each attempt gets a fresh harness and executable.
+//
+// Errors are matched using errors.Is & errors.As, so test functions must wrap them (with %w) for retries to kick in.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times the test case is run, including the first attempt. Values below 2
+	// disable retries.
+	MaxAttempts int
+
+	// RetryableErrors are the kinds of errors worth retrying on. Other errors fail the test case right away.
+	RetryableErrors []RetryableErrorClass
+}
+
+// ShouldRetry returns true if err (returned by the given attempt, starting at 1) should be followed by another attempt
+func (p RetryPolicy) ShouldRetry(err error, attempt int) bool {
+	return err != nil && attempt < p.MaxAttempts && p.IsRetryable(err)
+}
+
+// IsRetryable returns true if err matches one of the policy's RetryableErrors
+func (p RetryPolicy) IsRetryable(err error) bool {
+	for _, errorClass := range p.RetryableErrors {
+		switch errorClass {
+		case TimeoutErrorClass:
+			if isTimeoutError(err) {
+				return true
+			}
+		case ConnectionRefusedErrorClass:
+			if errors.Is(err, syscall.ECONNREFUSED) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func isTimeoutError(err error) bool {
+	var timeoutError *internal.TimeoutError
+	if errors.As(err, &timeoutError) {
+		return true
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded) {
+		return true
+	}
+
+	var netError net.Error
+	return errors.As(err, &netError) && netError.Timeout()
+}
@@ -0,0 +1,35 @@
+package tester_definition
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-io/tester-utils/internal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	policy := RetryPolicy{
+		MaxAttempts:     3,
+		RetryableErrors: []RetryableErrorClass{TimeoutErrorClass, ConnectionRefusedErrorClass},
+	}
+
+	connectionRefusedErr := fmt.Errorf("failed to connect: %w", syscall.ECONNREFUSED)
+
+	assert.True(t, policy.ShouldRetry(connectionRefusedErr, 1))
+	assert.True(t, policy.ShouldRetry(&internal.TimeoutError{Timeout: time.Second}, 2))
+	assert.True(t, policy.ShouldRetry(fmt.Errorf("read failed: %w", context.DeadlineExceeded), 1))
+
+	assert.False(t, policy.ShouldRetry(connectionRefusedErr, 3), "attempts exhausted")
+	assert.False(t, policy.ShouldRetry(nil, 1))
+	assert.False(t, policy.ShouldRetry(errors.New("expected +PONG, got +PING"), 1))
+
+	timeoutOnlyPolicy := RetryPolicy{MaxAttempts: 3, RetryableErrors: []RetryableErrorClass{TimeoutErrorClass}}
+	assert.False(t, timeoutOnlyPolicy.ShouldRetry(connectionRefusedErr, 1))
+
+	assert.False(t, RetryPolicy{}.ShouldRetry(connectionRefusedErr, 1), "zero value disables retries")
+}
@@ -3,22 +3,48 @@ package tester_definition
 import (
 	"time"
 
+	"github.com/codecrafters-io/tester-utils/logger"
 	"github.com/codecrafters-io/tester-utils/test_case_harness"
 )
 
 // TestCase represents a test case that'll be run against the user's code.
 //
-// For now, we only support one test case per stage. This may change in the future.
-//
-// We enforce the one-test-case-per-stage rule by requiring that the test case's slug matches the stage's slug (from the YAML definition).
+// Each stage has exactly one test case, whose slug matches the stage's slug (from the YAML definition). Stages that need
+// several independent tests can split their test case into sub-tests (see SubTestCases).
 type TestCase struct {
-	// Slug is the unique identifier for this test case. For now, it must match the slug of the stage from the course's YAML definition.
+	// Slug is the unique identifier for this test case. It must match the slug of the stage from the course's YAML definition.
 	Slug string
 
+	// TestFunc is the function that'll be run against the user's code. Not used if SubTestCases is set.
+	TestFunc func(testCaseHarness *test_case_harness.TestCaseHarness) error
+
+	// Timeout is the maximum amount of time that the test case can run for. Not used if SubTestCases is set.
+	Timeout time.Duration
+
+	// SubTestCases are run in order instead of TestFunc. Each one gets its own harness (and so its own teardown funcs)
+	// and timeout, and is reported individually. The stage fails as soon as one of them fails.
+	SubTestCases []SubTestCase
+
+	// IsParallelSafe allows an anti-cheat test case to run concurrently with neighbouring anti-cheat test cases that are
+	// also parallel-safe. Only set this for test cases that don't share state (like ports or files) with others.
+	// BeforeEach & AfterEach hooks are run concurrently too. Ignored for regular test cases, since stages build on each
+	// other.
+	IsParallelSafe bool
+
+	// RetryPolicy can be set for stages that are known to be flaky on slow machines (like network-timing stages). The
+	// zero value disables retries. With SubTestCases, all sub-tests are re-run.
+	RetryPolicy RetryPolicy
+}
+
+// SubTestCase is one of the named tests that make up a stage (see TestCase.SubTestCases)
+type SubTestCase struct {
+	// Name identifies the sub-test within its stage, and is used as a secondary log prefix. Example: "ping-1"
+	Name string
+
 	// TestFunc is the function that'll be run against the user's code.
 	TestFunc func(testCaseHarness *test_case_harness.TestCaseHarness) error
 
-	// Timeout is the maximum amount of time that the test case can run for.
+	// Timeout is the maximum amount of time that the sub-test can run for.
 	Timeout time.Duration
 }
 
@@ -37,6 +63,48 @@ type TesterDefinition struct {
 
 	TestCases          []TestCase
 	AntiCheatTestCases []TestCase
+
+	// LogRedactor can be set to rewrite tester-specific sensitive or noisy values (like tokens) in logs.
+	// Values of CODECRAFTERS_SECRET* environment variables are always redacted.
+	LogRedactor *logger.Redactor
+
+	// BeforeAll is run once before the first stage. Use it for work that is shared by all stages (including anti-cheat
+	// stages), like compiling fixtures or starting a mock server. If it returns an error, no stages are run. Teardown
+	// funcs registered on its harness are run after AfterAll.
+	BeforeAll func(harness *test_case_harness.TestCaseHarness) error
+
+	// AfterAll is run once after the last stage (or anti-cheat stage), even if a stage failed. Errors are logged in
+	// debug mode, they don't fail the run.
+	AfterAll func(harness *test_case_harness.TestCaseHarness) error
+
+	// HookTimeout is the maximum amount of time that each hook can run for. Defaults to 10 seconds. BeforeEach &
+	// AfterEach don't count towards the stage's timeout.
+	HookTimeout time.Duration
+
+	// BeforeEach is run before each stage's TestFunc, with the same harness. Returning an error fails the stage without
+	// running TestFunc. For stages with SubTestCases, it's run once before the first sub-test, with a harness of its own.
+	BeforeEach func(harness *test_case_harness.TestCaseHarness) error
+
+	// AfterEach is run after each stage's TestFunc (before teardown funcs), even if TestFunc failed, panicked or timed
+	// out. Returning an error fails the stage. Like BeforeEach, it's run once for stages with SubTestCases (after the
+	// last sub-test).
+	AfterEach func(harness *test_case_harness.TestCaseHarness) error
+}
+
+func (t TesterDefinition) CustomOrDefaultHookTimeout() time.Duration {
+	if t.HookTimeout == 0 {
+		return 10 * time.Second
+	}
+
+	return t.HookTimeout
+}
+
+func (t SubTestCase) CustomOrDefaultTimeout() time.Duration {
+	if t.Timeout == 0 {
+		return 10 * time.Second
+	}
+
+	return t.Timeout
 }
 
 func (t TesterDefinition) TestCaseBySlug(slug string) TestCase {
@@ -1,12 +1,21 @@
 package tester_utils
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"syscall"
 	"testing"
+	"time"
 
+	"github.com/codecrafters-io/tester-utils/logger"
 	"github.com/codecrafters-io/tester-utils/test_case_harness"
+	"github.com/codecrafters-io/tester-utils/test_report"
 	"github.com/codecrafters-io/tester-utils/tester_definition"
 	"github.com/stretchr/testify/assert"
 )
@@ -64,3 +73,979 @@ func TestOneStageFails(t *testing.T) {
 	exitCode := RunCLI(env, definition)
 	assert.Equal(t, exitCode, 1)
 }
+
+func TestJSONOutputFormat(t *testing.T) {
+	definition := tester_definition.TesterDefinition{
+		TestCases: []tester_definition.TestCase{
+			{Slug: "test-1", TestFunc: func(harness *test_case_harness.TestCaseHarness) error {
+				harness.Logger.Infof("Hello from %s", "test-1")
+				return nil
+			}},
+			{Slug: "test-2", TestFunc: failFunc},
+		},
+	}
+
+	env := map[string]string{
+		"CODECRAFTERS_REPOSITORY_DIR":  "./test_helpers/valid_app_dir",
+		"CODECRAFTERS_TEST_CASES_JSON": buildTestCasesJson([]string{"test-1", "test-2"}),
+		"CODECRAFTERS_OUTPUT_FORMAT":   "json",
+	}
+
+	output := bytes.NewBuffer([]byte{})
+	logger.SetDefaultWriter(output)
+	defer logger.SetDefaultWriter(nil)
+
+	exitCode := RunCLI(env, definition)
+	assert.Equal(t, 1, exitCode)
+
+	events := []logger.Event{}
+	for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+		event := logger.Event{}
+		if !assert.NoError(t, json.Unmarshal([]byte(line), &event), line) {
+			t.FailNow()
+		}
+		events = append(events, event)
+	}
+
+	assert.Equal(t, logger.Event{Type: logger.StageStartedEventType, Prefix: "test-1", StageSlug: "test-1", StageTitle: "Stage #1: test-1"}, events[0])
+	assert.Equal(t, logger.Event{Type: logger.LogEventType, Level: "info", Prefix: "test-1", Message: "Running tests for Stage #1: test-1"}, events[1])
+	assert.Equal(t, logger.Event{Type: logger.LogEventType, Level: "info", Prefix: "test-1", Message: "Hello from test-1"}, events[2])
+	assert.Equal(t, logger.StageFinishedEventType, events[4].Type)
+	assert.Equal(t, "passed", events[4].Status)
+
+	lastStageEvent := events[len(events)-2]
+	assert.Equal(t, logger.StageFinishedEventType, lastStageEvent.Type)
+	assert.Equal(t, "failed", lastStageEvent.Status)
+	assert.Equal(t, "fail", lastStageEvent.Error)
+
+	assert.Equal(t, logger.Event{Type: logger.ResultEventType, Status: "failed"}, events[len(events)-1])
+}
+
+func TestInternalErrorsInJSONOutputFormat(t *testing.T) {
+	definition := tester_definition.TesterDefinition{
+		TestCases: []tester_definition.TestCase{
+			{Slug: "test-1", TestFunc: passFunc},
+		},
+	}
+
+	env := map[string]string{
+		"CODECRAFTERS_REPOSITORY_DIR":   "./test_helpers/valid_app_dir",
+		"CODECRAFTERS_TEST_CASES_JSON":  buildTestCasesJson([]string{"test-1"}),
+		"CODECRAFTERS_OUTPUT_FORMAT":    "json",
+		"CODECRAFTERS_JSON_REPORT_PATH": path.Join(t.TempDir(), "missing_dir", "report.json"),
+	}
+
+	output := bytes.NewBuffer([]byte{})
+	logger.SetDefaultWriter(output)
+	defer logger.SetDefaultWriter(nil)
+
+	exitCode := RunCLI(env, definition)
+	assert.Equal(t, InternalErrorExitCode, exitCode)
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	for _, line := range lines {
+		assert.True(t, json.Valid([]byte(line)), line)
+	}
+
+	lastEvent := logger.Event{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[len(lines)-1]), &lastEvent))
+	assert.Equal(t, logger.LogEventType, lastEvent.Type)
+	assert.Equal(t, "error", lastEvent.Level)
+	assert.Contains(t, lastEvent.Message, "CodeCrafters internal error. Error writing test report")
+}
+
+func TestInvalidContextInJSONOutputFormat(t *testing.T) {
+	definition := tester_definition.TesterDefinition{
+		TestCases: []tester_definition.TestCase{
+			{Slug: "test-1", TestFunc: passFunc},
+		},
+	}
+
+	env := map[string]string{
+		"CODECRAFTERS_REPOSITORY_DIR":  "./test_helpers/valid_app_dir",
+		"CODECRAFTERS_TEST_CASES_JSON": "not json",
+		"CODECRAFTERS_OUTPUT_FORMAT":   "json",
+	}
+
+	output := bytes.NewBuffer([]byte{})
+	logger.SetDefaultWriter(output)
+	defer logger.SetDefaultWriter(nil)
+
+	exitCode := RunCLI(env, definition)
+	assert.Equal(t, InternalErrorExitCode, exitCode)
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	assert.Len(t, lines, 1)
+
+	event := logger.Event{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &event))
+	assert.Equal(t, "error", event.Level)
+	assert.Contains(t, event.Message, "failed to parse CODECRAFTERS_TEST_CASES_JSON")
+}
+
+func TestLogRedaction(t *testing.T) {
+	os.Setenv("CODECRAFTERS_SECRET_API_KEY", "secret-key-123")
+	defer os.Unsetenv("CODECRAFTERS_SECRET_API_KEY")
+
+	definition := tester_definition.TesterDefinition{
+		TestCases: []tester_definition.TestCase{
+			{Slug: "test-1", TestFunc: func(harness *test_case_harness.TestCaseHarness) error {
+				harness.Logger.Infof("Using key %s", "secret-key-123")
+				return fmt.Errorf("session %s expired", "abc-xyz")
+			}},
+		},
+		LogRedactor: logger.NewRedactor().AddLiteral("abc-xyz", "<session>"),
+	}
+
+	reportPath := path.Join(t.TempDir(), "report.json")
+	env := map[string]string{
+		"CODECRAFTERS_REPOSITORY_DIR":   "./test_helpers/valid_app_dir",
+		"CODECRAFTERS_TEST_CASES_JSON":  buildTestCasesJson([]string{"test-1"}),
+		"CODECRAFTERS_JSON_REPORT_PATH": reportPath,
+	}
+
+	output := bytes.NewBuffer([]byte{})
+	logger.SetDefaultWriter(output)
+	defer logger.SetDefaultWriter(nil)
+
+	exitCode := RunCLI(env, definition)
+	assert.Equal(t, 1, exitCode)
+
+	assert.NotContains(t, output.String(), "secret-key-123")
+	assert.Contains(t, output.String(), "Using key <redacted:CODECRAFTERS_SECRET_API_KEY>")
+	assert.NotContains(t, output.String(), "abc-xyz")
+	assert.Contains(t, output.String(), "session <session> expired")
+
+	// Reports are redacted too
+	reportBytes, err := os.ReadFile(reportPath)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(reportBytes), "secret-key-123")
+	assert.NotContains(t, string(reportBytes), "abc-xyz")
+
+	report := test_report.Report{}
+	assert.NoError(t, json.Unmarshal(reportBytes, &report))
+	assert.Equal(t, "session <session> expired", report.Stages[0].FailureMessage)
+}
+
+func TestDebugContextUsesDefaultWriter(t *testing.T) {
+	definition := tester_definition.TesterDefinition{
+		TestCases: []tester_definition.TestCase{
+			{Slug: "test-1", TestFunc: passFunc},
+		},
+	}
+
+	env := map[string]string{
+		"CODECRAFTERS_REPOSITORY_DIR":  "./test_helpers/debug_app_dir",
+		"CODECRAFTERS_TEST_CASES_JSON": buildTestCasesJson([]string{"test-1"}),
+		"CODECRAFTERS_COLOR":           "never",
+	}
+
+	output := bytes.NewBuffer([]byte{})
+	logger.SetDefaultWriter(output)
+	defer logger.SetDefaultWriter(nil)
+
+	exitCode := RunCLI(env, definition)
+	assert.Equal(t, 0, exitCode)
+	assert.True(t, strings.HasPrefix(output.String(), "Debug = true\n\n[test-1] Running tests for Stage #1: test-1\n"))
+}
+
+func TestFullLogFile(t *testing.T) {
+	definition := tester_definition.TesterDefinition{
+		TestCases: []tester_definition.TestCase{
+			{Slug: "test-1", TestFunc: func(harness *test_case_harness.TestCaseHarness) error {
+				harness.Logger.Debugf("Debug details")
+				return nil
+			}},
+		},
+	}
+
+	fullLogPath := path.Join(t.TempDir(), "full.log")
+	env := map[string]string{
+		"CODECRAFTERS_REPOSITORY_DIR":  "./test_helpers/valid_app_dir",
+		"CODECRAFTERS_TEST_CASES_JSON": buildTestCasesJson([]string{"test-1"}),
+		"CODECRAFTERS_FULL_LOG_PATH":   fullLogPath,
+	}
+
+	output := bytes.NewBuffer([]byte{})
+	logger.SetDefaultWriter(output)
+	defer logger.SetDefaultWriter(nil)
+
+	exitCode := RunCLI(env, definition)
+	assert.Equal(t, 0, exitCode)
+	assert.NotContains(t, output.String(), "Debug details")
+
+	fullLog, err := os.ReadFile(fullLogPath)
+	assert.NoError(t, err)
+	assert.Contains(t, string(fullLog), "[test-1] Running tests for Stage #1: test-1\n")
+	assert.Contains(t, string(fullLog), "[test-1] Debug details\n")
+	assert.Contains(t, string(fullLog), "[test-1] Test passed.\n")
+}
+
+func TestFullLogFileCreationFailure(t *testing.T) {
+	definition := tester_definition.TesterDefinition{
+		TestCases: []tester_definition.TestCase{
+			{Slug: "test-1", TestFunc: passFunc},
+		},
+	}
+
+	env := map[string]string{
+		"CODECRAFTERS_REPOSITORY_DIR":  "./test_helpers/valid_app_dir",
+		"CODECRAFTERS_TEST_CASES_JSON": buildTestCasesJson([]string{"test-1"}),
+		"CODECRAFTERS_FULL_LOG_PATH":   path.Join(t.TempDir(), "missing_dir", "full.log"),
+	}
+
+	output := bytes.NewBuffer([]byte{})
+	logger.SetDefaultWriter(output)
+	defer logger.SetDefaultWriter(nil)
+
+	exitCode := RunCLI(env, definition)
+	assert.Equal(t, InternalErrorExitCode, exitCode)
+	assert.Contains(t, output.String(), "CodeCrafters internal error. Error creating full log file")
+}
+
+func TestCriticalLogsInAllStages(t *testing.T) {
+	sharedHelper := func(harness *test_case_harness.TestCaseHarness) error {
+		harness.Logger.Infof("Checking for cheats")
+		harness.Logger.Criticalf("Suspicious output detected")
+		return nil
+	}
+
+	definition := tester_definition.TesterDefinition{
+		TestCases: []tester_definition.TestCase{
+			{Slug: "test-1", TestFunc: sharedHelper},
+		},
+		AntiCheatTestCases: []tester_definition.TestCase{
+			{Slug: "anti-cheat-1", TestFunc: sharedHelper},
+		},
+	}
+
+	env := map[string]string{
+		"CODECRAFTERS_REPOSITORY_DIR":  "./test_helpers/valid_app_dir",
+		"CODECRAFTERS_TEST_CASES_JSON": buildTestCasesJson([]string{"test-1"}),
+	}
+
+	output := bytes.NewBuffer([]byte{})
+	logger.SetDefaultWriter(output)
+	defer logger.SetDefaultWriter(nil)
+
+	exitCode := RunCLI(env, definition)
+	assert.Equal(t, 0, exitCode)
+
+	// Info logs are only shown for the regular stage, critical logs are shown for both
+	assert.Equal(t, 1, strings.Count(output.String(), "Checking for cheats"))
+	assert.Equal(t, 2, strings.Count(output.String(), "Suspicious output detected"))
+}
+
+func TestTimedOutStageIsCancelled(t *testing.T) {
+	exitCodeChannel := make(chan int, 1)
+
+	definition := tester_definition.TesterDefinition{
+		TestCases: []tester_definition.TestCase{
+			{
+				Slug: "test-1",
+				TestFunc: func(harness *test_case_harness.TestCaseHarness) error {
+					e := harness.NewExecutable()
+					e.Path = "sleep"
+
+					if err := e.Start("60"); err != nil {
+						return err
+					}
+
+					result, _ := e.Wait()
+					harness.Logger.Infof("Ghost log")
+					exitCodeChannel <- result.ExitCode
+
+					return nil
+				},
+				Timeout: 100 * time.Millisecond,
+			},
+		},
+	}
+
+	env := map[string]string{
+		"CODECRAFTERS_REPOSITORY_DIR":  "./test_helpers/valid_app_dir",
+		"CODECRAFTERS_TEST_CASES_JSON": buildTestCasesJson([]string{"test-1"}),
+	}
+
+	output := bytes.NewBuffer([]byte{})
+	logger.SetDefaultWriter(output)
+	defer logger.SetDefaultWriter(nil)
+
+	exitCode := RunCLI(env, definition)
+	assert.Equal(t, 1, exitCode)
+
+	select {
+	case processExitCode := <-exitCodeChannel:
+		assert.Equal(t, 137, processExitCode) // SIGKILL
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the program to be killed after the timeout")
+	}
+
+	assert.Contains(t, output.String(), "timed out")
+	assert.NotContains(t, output.String(), "Ghost log")
+}
+
+func TestPanicInTestFunc(t *testing.T) {
+	isTeardownRun := false
+
+	definition := tester_definition.TesterDefinition{
+		TestCases: []tester_definition.TestCase{
+			{Slug: "test-1", TestFunc: func(harness *test_case_harness.TestCaseHarness) error {
+				harness.RegisterTeardownFunc(func() { isTeardownRun = true })
+
+				var values []int
+				_ = values[1]
+
+				return nil
+			}},
+		},
+	}
+
+	fullLogPath := path.Join(t.TempDir(), "full.log")
+	env := map[string]string{
+		"CODECRAFTERS_REPOSITORY_DIR":  "./test_helpers/valid_app_dir",
+		"CODECRAFTERS_TEST_CASES_JSON": buildTestCasesJson([]string{"test-1"}),
+		"CODECRAFTERS_FULL_LOG_PATH":   fullLogPath,
+	}
+
+	output := bytes.NewBuffer([]byte{})
+	logger.SetDefaultWriter(output)
+	defer logger.SetDefaultWriter(nil)
+
+	exitCode := RunCLI(env, definition)
+	assert.Equal(t, InternalErrorExitCode, exitCode)
+	assert.True(t, isTeardownRun, "Expected teardown funcs to run")
+
+	assert.Contains(t, output.String(), "CodeCrafters internal error. Test function panicked: runtime error: index out of range")
+	assert.NotContains(t, output.String(), "goroutine")
+
+	// The stack trace is a debug log
+	fullLog, err := os.ReadFile(fullLogPath)
+	assert.NoError(t, err)
+	assert.Contains(t, string(fullLog), "runtime/debug.Stack()")
+}
+
+func TestPanicInAntiCheatTestFuncInDebugMode(t *testing.T) {
+	definition := tester_definition.TesterDefinition{
+		TestCases: []tester_definition.TestCase{
+			{Slug: "test-1", TestFunc: passFunc},
+		},
+		AntiCheatTestCases: []tester_definition.TestCase{
+			{Slug: "anti-cheat-1", TestFunc: func(harness *test_case_harness.TestCaseHarness) error {
+				panic("oops")
+			}},
+		},
+	}
+
+	env := map[string]string{
+		"CODECRAFTERS_REPOSITORY_DIR":  "./test_helpers/debug_app_dir",
+		"CODECRAFTERS_TEST_CASES_JSON": buildTestCasesJson([]string{"test-1"}),
+	}
+
+	output := bytes.NewBuffer([]byte{})
+	logger.SetDefaultWriter(output)
+	defer logger.SetDefaultWriter(nil)
+
+	exitCode := RunCLI(env, definition)
+	assert.Equal(t, InternalErrorExitCode, exitCode)
+	assert.Contains(t, output.String(), "CodeCrafters internal error. Test function panicked: oops")
+	assert.Contains(t, output.String(), "runtime/debug.Stack()")
+
+	// Outside debug mode, anti-cheat stages stay quiet
+	env["CODECRAFTERS_REPOSITORY_DIR"] = "./test_helpers/valid_app_dir"
+
+	output.Reset()
+	exitCode = RunCLI(env, definition)
+	assert.Equal(t, InternalErrorExitCode, exitCode)
+	assert.Contains(t, output.String(), "CodeCrafters internal error. Test function panicked: oops")
+	assert.NotContains(t, output.String(), "goroutine")
+}
+
+func TestContinueOnFailure(t *testing.T) {
+	definition := tester_definition.TesterDefinition{
+		TestCases: []tester_definition.TestCase{
+			{Slug: "test-1", TestFunc: passFunc},
+			{Slug: "test-2", TestFunc: failFunc},
+			{Slug: "test-3", TestFunc: passFunc},
+		},
+	}
+
+	env := map[string]string{
+		"CODECRAFTERS_REPOSITORY_DIR":      "./test_helpers/valid_app_dir",
+		"CODECRAFTERS_TEST_CASES_JSON":     buildTestCasesJson([]string{"test-1", "test-2", "test-3"}),
+		"CODECRAFTERS_CONTINUE_ON_FAILURE": "true",
+		"CODECRAFTERS_COLOR":               "never",
+	}
+
+	output := bytes.NewBuffer([]byte{})
+	logger.SetDefaultWriter(output)
+	defer logger.SetDefaultWriter(nil)
+
+	exitCode := RunCLI(env, definition)
+	assert.Equal(t, 1, exitCode)
+
+	assert.Contains(t, output.String(), "[test-3] Test passed.")
+
+	summary := output.String()[strings.Index(output.String(), "Summary:"):]
+	assert.Regexp(t, `Stage #1: test-1  passed  \d+(\.\d+)?[mµn]?s\n`, summary)
+	assert.Regexp(t, `Stage #2: test-2  failed  \d+(\.\d+)?[mµn]?s\n`, summary)
+	assert.Regexp(t, `Stage #3: test-3  passed  \d+(\.\d+)?[mµn]?s\n`, summary)
+	assert.Contains(t, summary, "2 passed, 1 failed, 0 skipped")
+}
+
+func TestReportFiles(t *testing.T) {
+	definition := tester_definition.TesterDefinition{
+		TestCases: []tester_definition.TestCase{
+			{Slug: "test-1", TestFunc: passFunc},
+			{Slug: "test-2", TestFunc: failFunc},
+			{Slug: "test-3", TestFunc: passFunc},
+		},
+	}
+
+	reportsDir := t.TempDir()
+	env := map[string]string{
+		"CODECRAFTERS_REPOSITORY_DIR":    "./test_helpers/valid_app_dir",
+		"CODECRAFTERS_TEST_CASES_JSON":   buildTestCasesJson([]string{"test-1", "test-2", "test-3"}),
+		"CODECRAFTERS_JSON_REPORT_PATH":  path.Join(reportsDir, "report.json"),
+		"CODECRAFTERS_JUNIT_REPORT_PATH": path.Join(reportsDir, "report.xml"),
+	}
+
+	logger.SetDefaultWriter(bytes.NewBuffer([]byte{}))
+	defer logger.SetDefaultWriter(nil)
+
+	exitCode := RunCLI(env, definition)
+	assert.Equal(t, 1, exitCode)
+
+	reportBytes, err := os.ReadFile(path.Join(reportsDir, "report.json"))
+	assert.NoError(t, err)
+
+	report := test_report.Report{}
+	assert.NoError(t, json.Unmarshal(reportBytes, &report))
+	assert.Equal(t, "failed", report.Status)
+	assert.Len(t, report.Stages, 3)
+
+	assert.Equal(t, "test-2", report.Stages[1].Slug)
+	assert.Equal(t, "test-2", report.Stages[1].LogPrefix)
+	assert.Equal(t, "failed", report.Stages[1].Status)
+	assert.Equal(t, "fail", report.Stages[1].FailureMessage)
+	assert.Equal(t, []string{"Running tests for Stage #2: test-2", "fail", "Test failed"}, getLogMessages(report.Stages[1]))
+	assert.Equal(t, "skipped", report.Stages[2].Status)
+
+	junitBytes, err := os.ReadFile(path.Join(reportsDir, "report.xml"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(junitBytes), `<testsuites tests="3" failures="1" skipped="1"`)
+}
+
+func TestReportFilesWithFailingAntiCheatStage(t *testing.T) {
+	definition := tester_definition.TesterDefinition{
+		TestCases: []tester_definition.TestCase{
+			{Slug: "test-1", TestFunc: passFunc},
+		},
+		AntiCheatTestCases: []tester_definition.TestCase{
+			{Slug: "anti-cheat-1", TestFunc: failFunc},
+		},
+	}
+
+	reportPath := path.Join(t.TempDir(), "report.json")
+	env := map[string]string{
+		"CODECRAFTERS_REPOSITORY_DIR":   "./test_helpers/valid_app_dir",
+		"CODECRAFTERS_TEST_CASES_JSON":  buildTestCasesJson([]string{"test-1"}),
+		"CODECRAFTERS_JSON_REPORT_PATH": reportPath,
+	}
+
+	logger.SetDefaultWriter(bytes.NewBuffer([]byte{}))
+	defer logger.SetDefaultWriter(nil)
+
+	exitCode := RunCLI(env, definition)
+	assert.Equal(t, 1, exitCode)
+
+	reportBytes, err := os.ReadFile(reportPath)
+	assert.NoError(t, err)
+
+	report := test_report.Report{}
+	assert.NoError(t, json.Unmarshal(reportBytes, &report))
+
+	// The stages all passed, the report must show why the run failed anyway (without revealing anti-cheat details)
+	assert.Equal(t, "failed", report.Status)
+	assert.Equal(t, "passed", report.Stages[0].Status)
+	assert.Equal(t, "failed", report.AntiCheatStatus)
+	assert.NotContains(t, string(reportBytes), "anti-cheat-1")
+}
+
+func getLogMessages(stage test_report.Stage) []string {
+	messages := []string{}
+	for _, logLine := range stage.LogLines {
+		messages = append(messages, logLine.Message)
+	}
+
+	return messages
+}
+
+func TestSubTestCases(t *testing.T) {
+	tornDown := []string{}
+
+	subTestFunc := func(name string, err error) func(harness *test_case_harness.TestCaseHarness) error {
+		return func(harness *test_case_harness.TestCaseHarness) error {
+			harness.RegisterTeardownFunc(func() { tornDown = append(tornDown, name) })
+			harness.Logger.Infof("Hello from %s", name)
+			return err
+		}
+	}
+
+	definition := tester_definition.TesterDefinition{
+		TestCases: []tester_definition.TestCase{
+			{Slug: "test-1", SubTestCases: []tester_definition.SubTestCase{
+				{Name: "first", TestFunc: subTestFunc("first", nil)},
+				{Name: "second", TestFunc: subTestFunc("second", errors.New("oops"))},
+				{Name: "third", TestFunc: subTestFunc("third", nil)},
+			}},
+		},
+	}
+
+	reportPath := path.Join(t.TempDir(), "report.json")
+	env := map[string]string{
+		"CODECRAFTERS_REPOSITORY_DIR":   "./test_helpers/valid_app_dir",
+		"CODECRAFTERS_TEST_CASES_JSON":  buildTestCasesJson([]string{"test-1"}),
+		"CODECRAFTERS_JSON_REPORT_PATH": reportPath,
+		"CODECRAFTERS_COLOR":            "never",
+	}
+
+	output := bytes.NewBuffer([]byte{})
+	logger.SetDefaultWriter(output)
+	defer logger.SetDefaultWriter(nil)
+
+	exitCode := RunCLI(env, definition)
+	assert.Equal(t, 1, exitCode)
+
+	assert.Equal(t, strings.Join([]string{
+		"[test-1] Running tests for Stage #1: test-1",
+		"[test-1] [first] Hello from first",
+		"[test-1] [first] Sub-test passed.",
+		"[test-1] [second] Hello from second",
+		"[test-1] [second] oops",
+		"[test-1] [second] Sub-test failed",
+		"[test-1] Test failed",
+		"",
+	}, "\n"), output.String())
+
+	// Each sub-test has its own teardown scope
+	assert.Equal(t, []string{"first", "second"}, tornDown)
+
+	reportBytes, err := os.ReadFile(reportPath)
+	assert.NoError(t, err)
+
+	report := test_report.Report{}
+	assert.NoError(t, json.Unmarshal(reportBytes, &report))
+	assert.Equal(t, "second: oops", report.Stages[0].FailureMessage)
+	assert.Equal(t, "passed", report.Stages[0].SubTests[0].Status)
+	assert.Equal(t, "failed", report.Stages[0].SubTests[1].Status)
+	assert.Equal(t, "oops", report.Stages[0].SubTests[1].FailureMessage)
+	assert.Equal(t, "skipped", report.Stages[0].SubTests[2].Status)
+}
+
+func TestSkipAndPassWithWarningsOutcomes(t *testing.T) {
+	definition := tester_definition.TesterDefinition{
+		TestCases: []tester_definition.TestCase{
+			{Slug: "test-1", TestFunc: passFunc},
+			{Slug: "test-2", TestFunc: func(harness *test_case_harness.TestCaseHarness) error {
+				return test_case_harness.Skip("not applicable")
+			}},
+			{Slug: "test-3", TestFunc: func(harness *test_case_harness.TestCaseHarness) error {
+				return test_case_harness.PassWithWarnings("response was slow")
+			}},
+		},
+	}
+
+	reportPath := path.Join(t.TempDir(), "report.json")
+	env := map[string]string{
+		"CODECRAFTERS_REPOSITORY_DIR":      "./test_helpers/valid_app_dir",
+		"CODECRAFTERS_TEST_CASES_JSON":     buildTestCasesJson([]string{"test-1", "test-2", "test-3"}),
+		"CODECRAFTERS_CONTINUE_ON_FAILURE": "true",
+		"CODECRAFTERS_JSON_REPORT_PATH":    reportPath,
+		"CODECRAFTERS_COLOR":               "never",
+	}
+
+	output := bytes.NewBuffer([]byte{})
+	logger.SetDefaultWriter(output)
+	defer logger.SetDefaultWriter(nil)
+
+	exitCode := RunCLI(env, definition)
+	assert.Equal(t, 0, exitCode)
+
+	assert.Contains(t, output.String(), "[test-2] Test skipped: not applicable\n")
+	assert.Contains(t, output.String(), "[test-3] Warning: response was slow\n[test-3] Test passed with warnings.\n")
+
+	summary := output.String()[strings.Index(output.String(), "Summary:"):]
+	assert.Regexp(t, `Stage #2: test-2  skipped               \d+(\.\d+)?[mµn]?s\n`, summary)
+	assert.Regexp(t, `Stage #3: test-3  passed with warnings  \d+(\.\d+)?[mµn]?s\n`, summary)
+	assert.Contains(t, summary, "1 passed, 1 passed with warnings, 0 failed, 1 skipped")
+
+	reportBytes, err := os.ReadFile(reportPath)
+	assert.NoError(t, err)
+
+	report := test_report.Report{}
+	assert.NoError(t, json.Unmarshal(reportBytes, &report))
+	assert.Equal(t, "passed", report.Status)
+	assert.Equal(t, "skipped", report.Stages[1].Status)
+	assert.Equal(t, "not applicable", report.Stages[1].SkipReason)
+	assert.Empty(t, report.Stages[1].FailureMessage)
+	assert.Equal(t, "passed_with_warnings", report.Stages[2].Status)
+	assert.Equal(t, []string{"response was slow"}, report.Stages[2].Warnings)
+}
+
+func TestHooks(t *testing.T) {
+	calls := []string{}
+	hook := func(name string) func(harness *test_case_harness.TestCaseHarness) error {
+		return func(harness *test_case_harness.TestCaseHarness) error {
+			calls = append(calls, name)
+			return nil
+		}
+	}
+
+	definition := tester_definition.TesterDefinition{
+		TestCases: []tester_definition.TestCase{
+			{Slug: "test-1", TestFunc: hook("test-1")},
+			{Slug: "test-2", TestFunc: hook("test-2")},
+		},
+		AntiCheatTestCases: []tester_definition.TestCase{
+			{Slug: "anti-cheat-1", TestFunc: hook("anti-cheat-1")},
+		},
+		BeforeAll: func(harness *test_case_harness.TestCaseHarness) error {
+			calls = append(calls, "before-all")
+			harness.RegisterTeardownFunc(func() { calls = append(calls, "before-all-teardown") })
+			return nil
+		},
+		AfterAll:   hook("after-all"),
+		BeforeEach: hook("before-each"),
+		AfterEach:  hook("after-each"),
+	}
+
+	env := map[string]string{
+		"CODECRAFTERS_REPOSITORY_DIR":  "./test_helpers/valid_app_dir",
+		"CODECRAFTERS_TEST_CASES_JSON": buildTestCasesJson([]string{"test-1", "test-2"}),
+	}
+
+	logger.SetDefaultWriter(bytes.NewBuffer([]byte{}))
+	defer logger.SetDefaultWriter(nil)
+
+	exitCode := RunCLI(env, definition)
+	assert.Equal(t, 0, exitCode)
+
+	assert.Equal(t, []string{
+		"before-all",
+		"before-each", "test-1", "after-each",
+		"before-each", "test-2", "after-each",
+		"before-each", "anti-cheat-1", "after-each",
+		"after-all", "before-all-teardown",
+	}, calls)
+}
+
+func TestFailingHooks(t *testing.T) {
+	testFuncCalled := false
+
+	definition := tester_definition.TesterDefinition{
+		TestCases: []tester_definition.TestCase{
+			{Slug: "test-1", TestFunc: func(harness *test_case_harness.TestCaseHarness) error {
+				testFuncCalled = true
+				return nil
+			}},
+		},
+		BeforeAll: func(harness *test_case_harness.TestCaseHarness) error {
+			return errors.New("failed to compile fixtures")
+		},
+	}
+
+	env := map[string]string{
+		"CODECRAFTERS_REPOSITORY_DIR":  "./test_helpers/valid_app_dir",
+		"CODECRAFTERS_TEST_CASES_JSON": buildTestCasesJson([]string{"test-1"}),
+		"CODECRAFTERS_COLOR":           "never",
+	}
+
+	output := bytes.NewBuffer([]byte{})
+	logger.SetDefaultWriter(output)
+	defer logger.SetDefaultWriter(nil)
+
+	exitCode := RunCLI(env, definition)
+	assert.Equal(t, 1, exitCode)
+	assert.False(t, testFuncCalled)
+	assert.Equal(t, "[tester] failed to compile fixtures\n[tester] Setup failed\n", output.String())
+
+	// Panics in BeforeAll are tester bugs
+	definition.BeforeAll = func(harness *test_case_harness.TestCaseHarness) error {
+		panic("oops")
+	}
+
+	output.Reset()
+	exitCode = RunCLI(env, definition)
+	assert.Equal(t, InternalErrorExitCode, exitCode)
+	assert.False(t, testFuncCalled)
+	assert.Contains(t, output.String(), "[tester] CodeCrafters internal error. Test function panicked: oops\n")
+
+	// AfterEach errors fail stages that passed
+	definition.BeforeAll = nil
+	definition.AfterEach = func(harness *test_case_harness.TestCaseHarness) error {
+		return errors.New("mock server received unexpected requests")
+	}
+
+	output.Reset()
+	exitCode = RunCLI(env, definition)
+	assert.Equal(t, 1, exitCode)
+	assert.True(t, testFuncCalled)
+	assert.Contains(t, output.String(), "[test-1] mock server received unexpected requests\n[test-1] Test failed\n")
+}
+
+func TestEachHooksRunOutsideTestTimeout(t *testing.T) {
+	var mutex sync.Mutex
+	calls := []string{}
+	record := func(name string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		calls = append(calls, name)
+	}
+
+	testFunc := func(name string, delay time.Duration) func(harness *test_case_harness.TestCaseHarness) error {
+		return func(harness *test_case_harness.TestCaseHarness) error {
+			time.Sleep(delay)
+			record(name)
+			return nil
+		}
+	}
+
+	definition := tester_definition.TesterDefinition{
+		TestCases: []tester_definition.TestCase{
+			{Slug: "test-1", TestFunc: testFunc("test-1", 0), Timeout: 200 * time.Millisecond},
+			{Slug: "test-2", TestFunc: testFunc("test-2", 2*time.Second), Timeout: 200 * time.Millisecond},
+			{Slug: "test-3", TestFunc: testFunc("test-3", 0), Timeout: 200 * time.Millisecond},
+		},
+		// BeforeEach takes longer than the stage timeout, but doesn't count towards it
+		BeforeEach: func(harness *test_case_harness.TestCaseHarness) error {
+			time.Sleep(300 * time.Millisecond)
+			record("before-each")
+			return nil
+		},
+		AfterEach: func(harness *test_case_harness.TestCaseHarness) error {
+			record("after-each")
+			return nil
+		},
+	}
+
+	env := map[string]string{
+		"CODECRAFTERS_REPOSITORY_DIR":      "./test_helpers/valid_app_dir",
+		"CODECRAFTERS_TEST_CASES_JSON":     buildTestCasesJson([]string{"test-1", "test-2", "test-3"}),
+		"CODECRAFTERS_CONTINUE_ON_FAILURE": "true",
+		"CODECRAFTERS_COLOR":               "never",
+	}
+
+	output := bytes.NewBuffer([]byte{})
+	logger.SetDefaultWriter(output)
+	defer logger.SetDefaultWriter(nil)
+
+	exitCode := RunCLI(env, definition)
+	assert.Equal(t, 1, exitCode)
+	assert.Contains(t, output.String(), "[test-2] timed out")
+
+	// AfterEach runs as soon as test-2 times out, before test-3 starts
+	mutex.Lock()
+	defer mutex.Unlock()
+	assert.Equal(t, []string{
+		"before-each", "test-1", "after-each",
+		"before-each", "after-each",
+		"before-each", "test-3", "after-each",
+	}, calls)
+}
+
+func TestEachHooksRunOnceForStagesWithSubTests(t *testing.T) {
+	calls := []string{}
+	hook := func(name string) func(harness *test_case_harness.TestCaseHarness) error {
+		return func(harness *test_case_harness.TestCaseHarness) error {
+			calls = append(calls, name)
+			return nil
+		}
+	}
+
+	definition := tester_definition.TesterDefinition{
+		TestCases: []tester_definition.TestCase{
+			{Slug: "test-1", SubTestCases: []tester_definition.SubTestCase{
+				{Name: "first", TestFunc: hook("first")},
+				{Name: "second", TestFunc: hook("second")},
+			}},
+		},
+		BeforeEach: hook("before-each"),
+		AfterEach:  hook("after-each"),
+	}
+
+	env := map[string]string{
+		"CODECRAFTERS_REPOSITORY_DIR":  "./test_helpers/valid_app_dir",
+		"CODECRAFTERS_TEST_CASES_JSON": buildTestCasesJson([]string{"test-1"}),
+	}
+
+	logger.SetDefaultWriter(bytes.NewBuffer([]byte{}))
+	defer logger.SetDefaultWriter(nil)
+
+	exitCode := RunCLI(env, definition)
+	assert.Equal(t, 0, exitCode)
+	assert.Equal(t, []string{"before-each", "first", "second", "after-each"}, calls)
+}
+
+func TestAfterEachRunsWhenTestFuncPanics(t *testing.T) {
+	isAfterEachRun := false
+
+	definition := tester_definition.TesterDefinition{
+		TestCases: []tester_definition.TestCase{
+			{Slug: "test-1", TestFunc: func(harness *test_case_harness.TestCaseHarness) error {
+				panic("oops")
+			}},
+		},
+		AfterEach: func(harness *test_case_harness.TestCaseHarness) error {
+			isAfterEachRun = true
+			return nil
+		},
+	}
+
+	env := map[string]string{
+		"CODECRAFTERS_REPOSITORY_DIR":  "./test_helpers/valid_app_dir",
+		"CODECRAFTERS_TEST_CASES_JSON": buildTestCasesJson([]string{"test-1"}),
+	}
+
+	output := bytes.NewBuffer([]byte{})
+	logger.SetDefaultWriter(output)
+	defer logger.SetDefaultWriter(nil)
+
+	exitCode := RunCLI(env, definition)
+	assert.Equal(t, InternalErrorExitCode, exitCode)
+	assert.True(t, isAfterEachRun, "Expected AfterEach to run")
+
+	// The panic is still reported as a tester bug
+	assert.Contains(t, output.String(), "CodeCrafters internal error. Test function panicked: oops")
+}
+
+func TestParallelAntiCheatTestCases(t *testing.T) {
+	// Each test func waits for the others to start, which only happens if they run concurrently
+	var arrivals sync.WaitGroup
+	arrivals.Add(3)
+
+	allArrived := make(chan struct{})
+	go func() {
+		arrivals.Wait()
+		close(allArrived)
+	}()
+
+	slowAntiCheatFunc := func(delay time.Duration, message string) func(harness *test_case_harness.TestCaseHarness) error {
+		return func(harness *test_case_harness.TestCaseHarness) error {
+			arrivals.Done()
+
+			select {
+			case <-allArrived:
+			case <-time.After(5 * time.Second):
+				harness.Logger.Criticalf("%s wasn't run concurrently", message)
+				return errors.New("not run concurrently")
+			}
+
+			// Finish in a different order than the test cases are listed in
+			time.Sleep(delay)
+			harness.Logger.Criticalf("%s", message)
+			return errors.New(message)
+		}
+	}
+
+	definition := tester_definition.TesterDefinition{
+		TestCases: []tester_definition.TestCase{
+			{Slug: "test-1", TestFunc: passFunc},
+		},
+		AntiCheatTestCases: []tester_definition.TestCase{
+			{Slug: "anti-cheat-1", TestFunc: slowAntiCheatFunc(300*time.Millisecond, "first"), IsParallelSafe: true},
+			{Slug: "anti-cheat-2", TestFunc: slowAntiCheatFunc(100*time.Millisecond, "second"), IsParallelSafe: true},
+			{Slug: "anti-cheat-3", TestFunc: slowAntiCheatFunc(200*time.Millisecond, "third"), IsParallelSafe: true},
+		},
+	}
+
+	env := map[string]string{
+		"CODECRAFTERS_REPOSITORY_DIR":  "./test_helpers/valid_app_dir",
+		"CODECRAFTERS_TEST_CASES_JSON": buildTestCasesJson([]string{"test-1"}),
+		"CODECRAFTERS_COLOR":           "never",
+	}
+
+	output := bytes.NewBuffer([]byte{})
+	logger.SetDefaultWriter(output)
+	defer logger.SetDefaultWriter(nil)
+
+	exitCode := RunCLI(env, definition)
+	assert.Equal(t, 1, exitCode)
+
+	// Failures are reported in the order of the test cases, not the order in which they finished
+	antiCheatOutput := output.String()[strings.Index(output.String(), "[test-1] Test passed.\n")+len("[test-1] Test passed.\n"):]
+	assert.Equal(t, "first\n\nsecond\n\nthird\n", antiCheatOutput)
+}
+
+func TestRetryPolicy(t *testing.T) {
+	attempts := 0
+	tornDown := 0
+
+	definition := tester_definition.TesterDefinition{
+		TestCases: []tester_definition.TestCase{
+			{
+				Slug: "test-1",
+				TestFunc: func(harness *test_case_harness.TestCaseHarness) error {
+					attempts++
+					harness.RegisterTeardownFunc(func() { tornDown++ })
+
+					if attempts < 3 {
+						return fmt.Errorf("failed to connect: %w", syscall.ECONNREFUSED)
+					}
+
+					return nil
+				},
+				RetryPolicy: tester_definition.RetryPolicy{
+					MaxAttempts:     3,
+					RetryableErrors: []tester_definition.RetryableErrorClass{tester_definition.ConnectionRefusedErrorClass},
+				},
+			},
+		},
+	}
+
+	reportPath := path.Join(t.TempDir(), "report.json")
+	env := map[string]string{
+		"CODECRAFTERS_REPOSITORY_DIR":   "./test_helpers/valid_app_dir",
+		"CODECRAFTERS_TEST_CASES_JSON":  buildTestCasesJson([]string{"test-1"}),
+		"CODECRAFTERS_JSON_REPORT_PATH": reportPath,
+		"CODECRAFTERS_COLOR":            "never",
+	}
+
+	output := bytes.NewBuffer([]byte{})
+	logger.SetDefaultWriter(output)
+	defer logger.SetDefaultWriter(nil)
+
+	exitCode := RunCLI(env, definition)
+	assert.Equal(t, 0, exitCode)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, 3, tornDown)
+
+	assert.Equal(t, strings.Join([]string{
+		"[test-1] Running tests for Stage #1: test-1",
+		"[test-1] failed to connect: connection refused",
+		"[test-1] Attempt 1 of 3 failed, retrying...",
+		"[test-1] failed to connect: connection refused",
+		"[test-1] Attempt 2 of 3 failed, retrying...",
+		"[test-1] Test passed.",
+		"",
+	}, "\n"), output.String())
+
+	reportBytes, err := os.ReadFile(reportPath)
+	assert.NoError(t, err)
+
+	report := test_report.Report{}
+	assert.NoError(t, json.Unmarshal(reportBytes, &report))
+	assert.Equal(t, 3, report.Stages[0].Attempts)
+
+	// Errors that don't match the policy aren't retried
+	attempts = 10
+	definition.TestCases[0].TestFunc = func(harness *test_case_harness.TestCaseHarness) error {
+		attempts++
+		return errors.New("expected +PONG, got +PING")
+	}
+
+	exitCode = RunCLI(env, definition)
+	assert.Equal(t, 1, exitCode)
+	assert.Equal(t, 11, attempts)
+}
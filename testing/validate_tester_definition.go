@@ -19,6 +19,8 @@ type courseYAML struct {
 }
 
 // ValidateTesterDefinitionAgainstYAML tests whether the stage slugs in TesterDefintion match those in the course YAML at yamlPath.
+//
+// Sub-tests (see tester_definition.TestCase.SubTestCases) aren't stages, so they don't need to appear in the YAML.
 func ValidateTesterDefinitionAgainstYAML(t testing.T, testerDefinition tester_definition.TesterDefinition, yamlPath string) {
 	bytes, err := os.ReadFile(yamlPath)
 	if err != nil {
@@ -45,3 +45,24 @@ func TestTestAgainstYAMLSuccess(t *testing.T) {
 
 	assert.False(t, runtimeT.Failed())
 }
+
+func TestTestAgainstYAMLWithSubTests(t *testing.T) {
+	definition := tester_definition.TesterDefinition{
+		TestCases: []tester_definition.TestCase{
+			{Slug: "init"},
+			{Slug: "ping-pong"},
+			{Slug: "ping-pong-multiple", SubTestCases: []tester_definition.SubTestCase{{Name: "ping-1"}, {Name: "ping-2"}}},
+			{Slug: "concurrent-clients"},
+			{Slug: "echo"},
+			{Slug: "set_get"},
+			{Slug: "expiry"},
+		},
+	}
+
+	runtimeT := &testingInterface.RuntimeT{}
+
+	yamlPath := "test_helpers/tester_definition_test/course_definition.yml"
+	ValidateTesterDefinitionAgainstYAML(runtimeT, definition, yamlPath)
+
+	assert.False(t, runtimeT.Failed())
+}